@@ -0,0 +1,70 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+)
+
+type (
+	// Authenticator adds credentials to every request sent to Jira,
+	// including retries.
+	Authenticator interface {
+		Authenticate(request *http.Request) error
+	}
+
+	// BasicAuth authenticates with a Jira username and password.
+	BasicAuth struct {
+		Username string
+		Password string
+	}
+
+	// BearerToken authenticates with a Jira Data Center personal access token.
+	BearerToken struct {
+		Token string
+	}
+
+	// APIToken authenticates against Jira Cloud with an account email and
+	// one of its API tokens.
+	APIToken struct {
+		Email string
+		Token string
+	}
+
+	// Anonymous sends requests without credentials, for instances that
+	// allow anonymous access.
+	Anonymous struct{}
+)
+
+func (a BasicAuth) Authenticate(request *http.Request) error {
+	request.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+func (a BearerToken) Authenticate(request *http.Request) error {
+	request.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+func (a APIToken) Authenticate(request *http.Request) error {
+	request.SetBasicAuth(a.Email, a.Token)
+	return nil
+}
+
+func (Anonymous) Authenticate(*http.Request) error {
+	return nil
+}
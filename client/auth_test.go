@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticators(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     Authenticator
+		expected string
+	}{
+		{"basic", BasicAuth{Username: "flyte", Password: "secret"}, "Basic Zmx5dGU6c2VjcmV0"},
+		{"personal access token", BearerToken{Token: "pat-123"}, "Bearer pat-123"},
+		{"cloud api token", APIToken{Email: "flyte@example.com", Token: "tok"}, "Basic Zmx5dGVAZXhhbXBsZS5jb206dG9r"},
+		{"anonymous", Anonymous{}, ""},
+		{"no authenticator", nil, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				okHandler(w, r)
+			}))
+			defer server.Close()
+
+			c := newTestClient(t, Config{Host: server.URL, Auth: tc.auth})
+			_, err := c.GetTransitions("TEST-1")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, authorization)
+		})
+	}
+}
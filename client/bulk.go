@@ -0,0 +1,154 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxBulkCreate is the most issues Jira creates with a single bulk request.
+const maxBulkCreate = 50
+
+type (
+	// BulkCreateResult is the outcome of creating one of the issues of a
+	// bulk creation: either the created issue or why it was not created.
+	BulkCreateResult struct {
+		Issue CreateIssueAPIResponse
+		Err   error
+	}
+
+	bulkCreateRequest struct {
+		IssueUpdates []Issue `json:"issueUpdates"`
+	}
+
+	bulkCreateResponse struct {
+		Issues []CreateIssueAPIResponse `json:"issues"`
+		Errors []bulkCreateError        `json:"errors"`
+	}
+
+	// bulkCreateError is why Jira refused the issue at FailedElementNumber
+	// of a bulk request.
+	bulkCreateError struct {
+		Status              int             `json:"status"`
+		ElementErrors       errorCollection `json:"elementErrors"`
+		FailedElementNumber int             `json:"failedElementNumber"`
+	}
+)
+
+// BulkCreateIssues creates issues, sending them to Jira in batches of
+// maxBulkCreate. Issues are validated one by one, so that a single invalid
+// issue does not keep the others from being created. The results are in the
+// order of issues.
+func (c *Client) BulkCreateIssues(issues []NewIssue) []BulkCreateResult {
+	results := make([]BulkCreateResult, len(issues))
+	var batch []int
+	var fields []IssueFields
+	for i, newIssue := range issues {
+		f, err := c.issueFields(newIssue)
+		if err == nil {
+			err = c.validateCreate(f)
+		}
+		if err != nil {
+			results[i].Err = fmt.Errorf("issueSummary='%s' : %w", newIssue.Summary, err)
+			continue
+		}
+		batch = append(batch, i)
+		fields = append(fields, f)
+
+		if len(batch) == maxBulkCreate {
+			c.bulkCreate(issues, batch, fields, results)
+			batch, fields = nil, nil
+		}
+	}
+	if len(batch) > 0 {
+		c.bulkCreate(issues, batch, fields, results)
+	}
+	return results
+}
+
+// bulkCreate creates the issues at the indexes batch of issues, whose fields
+// are given, and records the outcome in results.
+func (c *Client) bulkCreate(issues []NewIssue, batch []int, fields []IssueFields, results []BulkCreateResult) {
+	fail := func(i int, err error) {
+		results[i].Err = fmt.Errorf("issueSummary='%s' : %w", issues[i].Summary, err)
+	}
+
+	response, err := c.sendBulkCreate(fields)
+	if err != nil {
+		for _, i := range batch {
+			fail(i, err)
+		}
+		return
+	}
+
+	failed := map[int]error{}
+	for _, e := range response.Errors {
+		jiraErr := &JiraError{StatusCode: e.Status}
+		if len(e.ElementErrors.ErrorMessages) > 0 {
+			jiraErr.Messages = e.ElementErrors.ErrorMessages
+		}
+		if len(e.ElementErrors.Errors) > 0 {
+			jiraErr.FieldErrors = e.ElementErrors.Errors
+		}
+		failed[e.FailedElementNumber] = jiraErr
+	}
+	// Jira lists the created issues in the order they were requested in
+	created := response.Issues
+	for n, i := range batch {
+		switch {
+		case failed[n] != nil:
+			fail(i, failed[n])
+		case len(created) > 0:
+			results[i].Issue = created[0]
+			created = created[1:]
+		default:
+			fail(i, errors.New("jira did not report whether the issue was created"))
+		}
+	}
+}
+
+func (c *Client) sendBulkCreate(fields []IssueFields) (bulkCreateResponse, error) {
+	var response bulkCreateResponse
+	body := bulkCreateRequest{IssueUpdates: make([]Issue, len(fields))}
+	for i, f := range fields {
+		body.IssueUpdates[i] = Issue{Fields: f}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return response, err
+	}
+
+	request, err := c.newRequest(http.MethodPost, "/rest/api/2/issue/bulk", b)
+	if err != nil {
+		return response, err
+	}
+	err = c.sendRequest(markRetryable(request), &response)
+
+	// when none of the issues could be created Jira answers with 400, but
+	// still tells what is wrong with each of them
+	var jiraErr *JiraError
+	if errors.As(err, &jiraErr) && jiraErr.StatusCode == http.StatusBadRequest {
+		var refused bulkCreateResponse
+		if json.Unmarshal(jiraErr.body, &refused) == nil && len(refused.Errors) > 0 {
+			return refused, nil
+		}
+	}
+	return response, err
+}
@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// bulkServer answers bulk create requests with respond, given the summaries
+// of the requested issues, and records the size of each request.
+func bulkServer(t *testing.T, respond func(w http.ResponseWriter, summaries []string)) (*Client, *[]int) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/issue/bulk" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			IssueUpdates []struct {
+				Fields struct {
+					Summary string `json:"summary"`
+				} `json:"fields"`
+			} `json:"issueUpdates"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		summaries := make([]string, len(body.IssueUpdates))
+		for i, update := range body.IssueUpdates {
+			summaries[i] = update.Fields.Summary
+		}
+		batches = append(batches, len(summaries))
+		respond(w, summaries)
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL}), &batches
+}
+
+func newIssues(n int) []NewIssue {
+	issues := make([]NewIssue, n)
+	for i := range issues {
+		issues[i] = NewIssue{Project: "FLYTE", IssueType: "Task", Summary: fmt.Sprint(i)}
+	}
+	return issues
+}
+
+func TestBulkCreateIssuesInBatches(t *testing.T) {
+	c, batches := bulkServer(t, func(w http.ResponseWriter, summaries []string) {
+		var created []CreateIssueAPIResponse
+		for _, summary := range summaries {
+			created = append(created, CreateIssueAPIResponse{Key: "FLYTE-" + summary})
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"issues": created, "errors": []interface{}{}})
+	})
+
+	results := c.BulkCreateIssues(newIssues(120))
+
+	assert.Equal(t, []int{50, 50, 20}, *batches)
+	require.Len(t, results, 120)
+	for i, result := range results {
+		assert.NoError(t, result.Err)
+		assert.Equal(t, fmt.Sprintf("FLYTE-%d", i), result.Issue.Key)
+	}
+}
+
+func TestBulkCreateIssuesWithPartialFailure(t *testing.T) {
+	c, _ := bulkServer(t, func(w http.ResponseWriter, summaries []string) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"issues": [{"key": "FLYTE-1"}, {"key": "FLYTE-3"}], "errors": [{"status": 400,
+			"elementErrors": {"errorMessages": [], "errors": {"assignee": "User 'nobody' does not exist."}}, "failedElementNumber": 1}]}`))
+	})
+	issues := newIssues(4)
+	issues[2].DueDate = "tomorrow"
+
+	results := c.BulkCreateIssues(issues)
+
+	assert.Equal(t, "FLYTE-1", results[0].Issue.Key)
+	assert.EqualError(t, results[1].Err, "issueSummary='1' : statusCode=400 : assignee: User 'nobody' does not exist.")
+	var validationErr *ValidationError
+	assert.True(t, errors.As(results[2].Err, &validationErr), "the invalid issue is not sent")
+	assert.Equal(t, "FLYTE-3", results[3].Issue.Key)
+}
+
+func TestBulkCreateIssuesAllRefused(t *testing.T) {
+	c, _ := bulkServer(t, func(w http.ResponseWriter, summaries []string) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"issues": [], "errors": [
+			{"status": 400, "elementErrors": {"errors": {"summary": "too long"}}, "failedElementNumber": 0},
+			{"status": 400, "elementErrors": {"errorMessages": ["no permission"]}, "failedElementNumber": 1}]}`))
+	})
+
+	results := c.BulkCreateIssues(newIssues(2))
+
+	assert.EqualError(t, results[0].Err, "issueSummary='0' : statusCode=400 : summary: too long")
+	assert.EqualError(t, results[1].Err, "issueSummary='1' : statusCode=400 : no permission")
+}
+
+func TestBulkCreateIssuesRequestFailure(t *testing.T) {
+	c, _ := bulkServer(t, func(w http.ResponseWriter, summaries []string) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	results := c.BulkCreateIssues(newIssues(2))
+
+	for _, result := range results {
+		var jiraErr *JiraError
+		require.True(t, errors.As(result.Err, &jiraErr))
+		assert.Equal(t, http.StatusUnauthorized, jiraErr.StatusCode)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"net/http"
+	"strings"
+)
+
+// clonersLinkType is the link type Jira relates clones to their original with.
+const clonersLinkType = "Cloners"
+
+type (
+	// CloneOptions selects what CloneIssue copies besides the summary,
+	// description, priority, labels and components of an issue.
+	CloneOptions struct {
+		// SummaryPrefix is put in front of the summary of the clones.
+		SummaryPrefix string
+		// Project is the project to clone into, the project of the
+		// original by default.
+		Project string
+		// CustomFields are the names or ids of the custom fields to copy.
+		CustomFields []string
+		// Subtasks clones the sub-tasks of the issue too.
+		Subtasks bool
+		// Links copies the links of the issue, and of its sub-tasks, to
+		// their clones.
+		Links bool
+	}
+
+	// CloneResult holds the keys of the clone of an issue and of the clones
+	// of its sub-tasks.
+	CloneResult struct {
+		Key      string
+		Subtasks []string
+	}
+
+	// rawIssue is an issue with its fields as Jira returns them.
+	rawIssue struct {
+		Key    string                 `json:"key"`
+		Fields map[string]interface{} `json:"fields"`
+	}
+)
+
+// CloneIssue copies the issue issueKey to a new issue, which is linked to the
+// original with a Cloners link. When cloning fails after the clone was
+// created, the result holds the issues created so far.
+func (c *Client) CloneIssue(issueKey string, options CloneOptions) (CloneResult, error) {
+	var result CloneResult
+	original, err := c.getRawIssue(issueKey)
+	if err != nil {
+		return result, err
+	}
+	if result.Key, err = c.cloneLinked(original, options, ""); err != nil {
+		return result, err
+	}
+	if !options.Subtasks {
+		return result, nil
+	}
+
+	subtasks, _ := original.Fields["subtasks"].([]interface{})
+	for _, subtask := range subtasks {
+		original, err := c.getRawIssue(valueKey(subtask, "key"))
+		if err != nil {
+			return result, fmt.Errorf("issue %s was cloned to %s : %w", issueKey, result.Key, err)
+		}
+		key, err := c.cloneLinked(original, options, result.Key)
+		if key != "" {
+			result.Subtasks = append(result.Subtasks, key)
+		}
+		if err != nil {
+			return result, fmt.Errorf("issue %s was cloned to %s : %w", issueKey, result.Key, err)
+		}
+	}
+	return result, nil
+}
+
+// getRawIssue returns the issue issueKey with the given fields, or all of
+// them when none is given.
+func (c *Client) getRawIssue(issueKey string, fields ...string) (rawIssue, error) {
+	var issue rawIssue
+	path := fmt.Sprintf("/rest/api/2/issue/%s", issueKey)
+	if len(fields) > 0 {
+		path += "?fields=" + strings.Join(fields, ",")
+	}
+	request, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return issue, err
+	}
+	if err := c.sendRequest(request, &issue); err != nil {
+		return rawIssue{}, fmt.Errorf("issueId=%s : %w", issueKey, err)
+	}
+	return issue, nil
+}
+
+// cloneLinked creates the clone of original, under parent when it is not
+// empty, and links it like the original if requested.
+func (c *Client) cloneLinked(original rawIssue, options CloneOptions, parent string) (string, error) {
+	newIssue, err := c.cloneOf(original, options, parent)
+	if err != nil {
+		return "", err
+	}
+	clone, err := c.CreateIssue(newIssue)
+	if err != nil {
+		return "", fmt.Errorf("issueId=%s : %w", original.Key, err)
+	}
+
+	if err := c.linkIssues(clone.Key, original.Key, clonersLinkType, nil); err != nil {
+		return clone.Key, fmt.Errorf("issue %s was cloned to %s : %w", original.Key, clone.Key, err)
+	}
+	if !options.Links {
+		return clone.Key, nil
+	}
+	links, _ := original.Fields["issuelinks"].([]interface{})
+	for _, link := range links {
+		linkType := valueKey(valueOf(link, "type"), "name")
+		if linkType == clonersLinkType {
+			// the clone did not clone what the original was cloned from
+			continue
+		}
+		// the original is the inward issue of the links listing the
+		// outward one and the other way round
+		inward, outward := clone.Key, valueKey(valueOf(link, "outwardIssue"), "key")
+		if outward == "" {
+			inward, outward = valueKey(valueOf(link, "inwardIssue"), "key"), clone.Key
+		}
+		if err := c.linkIssues(inward, outward, linkType, nil); err != nil {
+			return clone.Key, fmt.Errorf("issue %s was cloned to %s : %w", original.Key, clone.Key, err)
+		}
+	}
+	return clone.Key, nil
+}
+
+// cloneOf returns the issue copying original as set by options.
+func (c *Client) cloneOf(original rawIssue, options CloneOptions, parent string) (NewIssue, error) {
+	fields := original.Fields
+	summary, _ := fields["summary"].(string)
+	newIssue := NewIssue{
+		Project:   options.Project,
+		IssueType: valueKey(fields["issuetype"], "name"),
+		Summary:   options.SummaryPrefix + summary,
+		Priority:  valueKey(fields["priority"], "name"),
+		Parent:    parent,
+	}
+	switch description := fields["description"].(type) {
+	case string:
+		newIssue.Description = description
+	case map[string]interface{}:
+		// a document on Jira Cloud
+		b, err := json.Marshal(description)
+		if err != nil {
+			return NewIssue{}, err
+		}
+		newIssue.Description, newIssue.Format = string(b), markup.ADF
+	}
+	if newIssue.Project == "" {
+		newIssue.Project = valueKey(fields["project"], "key")
+	}
+	if newIssue.Parent == "" {
+		// sub-tasks are cloned under the same parent
+		newIssue.Parent = valueKey(fields["parent"], "key")
+	}
+	labels, _ := fields["labels"].([]interface{})
+	for _, label := range labels {
+		if s, ok := label.(string); ok {
+			newIssue.Labels = append(newIssue.Labels, s)
+		}
+	}
+	components, _ := fields["components"].([]interface{})
+	for _, component := range components {
+		newIssue.Components = append(newIssue.Components, valueKey(component, "name"))
+	}
+
+	for _, name := range options.CustomFields {
+		matches, err := c.lookupField(name, false)
+		if err != nil {
+			return NewIssue{}, fmt.Errorf("issueId=%s : %w", original.Key, err)
+		}
+		if len(matches) != 1 {
+			return NewIssue{}, fmt.Errorf("issueId=%s : %w %q: it must match exactly one field", original.Key, ErrInvalidField, name)
+		}
+		if value, ok := fields[matches[0].ID]; ok && value != nil {
+			if newIssue.CustomFields == nil {
+				newIssue.CustomFields = map[string]interface{}{}
+			}
+			newIssue.CustomFields[matches[0].ID] = value
+		}
+	}
+	return newIssue, nil
+}
+
+// valueOf returns the value of key of the JSON object value, nil when value is
+// not an object.
+func valueOf(value interface{}, key string) interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
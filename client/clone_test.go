@@ -0,0 +1,174 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	originalIssue = `{"key": "FLYTE-1", "fields": {
+		"summary": "deploy the pack",
+		"description": "deploy it everywhere",
+		"project": {"key": "FLYTE"},
+		"issuetype": {"name": "Task"},
+		"priority": {"name": "Major"},
+		"labels": ["deployment"],
+		"components": [{"id": "1", "name": "pack"}],
+		"customfield_10002": 3,
+		"customfield_10003": {"id": "7", "value": "Platform"},
+		"customfield_10005": null,
+		"subtasks": [{"key": "FLYTE-2"}],
+		"issuelinks": [
+			{"type": {"name": "Blocks"}, "outwardIssue": {"key": "OPS-1"}},
+			{"type": {"name": "Relates"}, "inwardIssue": {"key": "OPS-2"}},
+			{"type": {"name": "Cloners"}, "outwardIssue": {"key": "FLYTE-0"}}
+		]
+	}}`
+
+	originalSubtask = `{"key": "FLYTE-2", "fields": {
+		"summary": "roll back",
+		"project": {"key": "FLYTE"},
+		"issuetype": {"name": "Sub-task"},
+		"parent": {"key": "FLYTE-1"}
+	}}`
+)
+
+type issueLinkRequest struct {
+	Type    string
+	Inward  string
+	Outward string
+}
+
+// cloneServer serves the original issue and its sub-task, records the fields
+// of the created issues and the links created between issues.
+func cloneServer(t *testing.T) (*Client, *[]map[string]interface{}, *[]issueLinkRequest) {
+	var created []map[string]interface{}
+	var links []issueLinkRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/field":
+			json.NewEncoder(w).Encode(testFields)
+		case "/rest/api/2/issue/FLYTE-1":
+			w.Write([]byte(originalIssue))
+		case "/rest/api/2/issue/FLYTE-2":
+			w.Write([]byte(originalSubtask))
+		case "/rest/api/2/issue/":
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body.Fields)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"key": "FLYTE-%d"}`, 10+len(created))
+		case "/rest/api/2/issueLink":
+			var body struct {
+				Type    IssueLink `json:"type"`
+				Inward  LinkIssue `json:"inwardIssue"`
+				Outward LinkIssue `json:"outwardIssue"`
+				Comment *Comment  `json:"comment"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Nil(t, body.Comment)
+			links = append(links, issueLinkRequest{body.Type.Name, body.Inward.Key, body.Outward.Key})
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL}), &created, &links
+}
+
+func TestCloneIssueCopiesFields(t *testing.T) {
+	c, created, links := cloneServer(t)
+
+	result, err := c.CloneIssue("FLYTE-1", CloneOptions{SummaryPrefix: "CLONE - ", CustomFields: []string{"Story Points", "customfield_10003", "Go Live"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, CloneResult{Key: "FLYTE-11"}, result)
+	require.Len(t, *created, 1)
+	fields := (*created)[0]
+	assert.Equal(t, "CLONE - deploy the pack", fields["summary"])
+	assert.Equal(t, "deploy it everywhere", fields["description"])
+	assert.Equal(t, map[string]interface{}{"key": "FLYTE"}, fields["project"])
+	assert.Equal(t, map[string]interface{}{"name": "Task"}, fields["issuetype"])
+	assert.Equal(t, map[string]interface{}{"name": "Major"}, fields["priority"])
+	assert.Equal(t, []interface{}{"deployment"}, fields["labels"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "pack"}}, fields["components"])
+	assert.Equal(t, float64(3), fields["customfield_10002"])
+	assert.Equal(t, map[string]interface{}{"id": "7", "value": "Platform"}, fields["customfield_10003"])
+	assert.NotContains(t, fields, "customfield_10005")
+	assert.NotContains(t, fields, "parent")
+	assert.Equal(t, []issueLinkRequest{{"Cloners", "FLYTE-11", "FLYTE-1"}}, *links)
+}
+
+func TestCloneIssueWithSubtasksAndLinks(t *testing.T) {
+	c, created, links := cloneServer(t)
+
+	result, err := c.CloneIssue("FLYTE-1", CloneOptions{Project: "OPS", Subtasks: true, Links: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, CloneResult{Key: "FLYTE-11", Subtasks: []string{"FLYTE-12"}}, result)
+	require.Len(t, *created, 2)
+	assert.Equal(t, map[string]interface{}{"key": "OPS"}, (*created)[0]["project"])
+	assert.Equal(t, "roll back", (*created)[1]["summary"])
+	assert.Equal(t, map[string]interface{}{"key": "OPS"}, (*created)[1]["project"])
+	assert.Equal(t, map[string]interface{}{"key": "FLYTE-11"}, (*created)[1]["parent"])
+	assert.Equal(t, []issueLinkRequest{
+		{"Cloners", "FLYTE-11", "FLYTE-1"},
+		{"Blocks", "FLYTE-11", "OPS-1"},
+		{"Relates", "OPS-2", "FLYTE-11"},
+		{"Cloners", "FLYTE-12", "FLYTE-2"},
+	}, *links)
+}
+
+func TestCloneSubtaskKeepsParent(t *testing.T) {
+	c, created, _ := cloneServer(t)
+
+	_, err := c.CloneIssue("FLYTE-2", CloneOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, *created, 1)
+	assert.Equal(t, map[string]interface{}{"key": "FLYTE-1"}, (*created)[0]["parent"])
+}
+
+func TestCloneIssueWithUnknownCustomField(t *testing.T) {
+	c, created, _ := cloneServer(t)
+
+	_, err := c.CloneIssue("FLYTE-1", CloneOptions{CustomFields: []string{"Severity"}})
+
+	assert.True(t, errors.Is(err, ErrInvalidField))
+	assert.Empty(t, *created)
+}
+
+func TestCloneIssueNotFound(t *testing.T) {
+	c, _, _ := cloneServer(t)
+
+	_, err := c.CloneIssue("FLYTE-404", CloneOptions{})
+
+	var jiraErr *JiraError
+	require.True(t, errors.As(err, &jiraErr))
+	assert.Equal(t, http.StatusNotFound, jiraErr.StatusCode)
+}
@@ -0,0 +1,372 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// The kinds of Jira deployment of Config.Deployment.
+const (
+	// DeploymentAuto asks the instance which kind it is, see
+	// DetectDeployment.
+	DeploymentAuto = "auto"
+	// DeploymentServer is Jira Server or Data Center, talked to through
+	// the REST API v2.
+	DeploymentServer = "server"
+	// DeploymentCloud is Jira Cloud, talked to through the REST API v3,
+	// which refers to users by account id and to rich text as Atlassian
+	// Document Format documents.
+	DeploymentCloud = "cloud"
+)
+
+// jqlSearchPath is the token paginated search of Jira Cloud, which replaces
+// the offset paginated /rest/api/3/search.
+const jqlSearchPath = "/rest/api/3/search/jql"
+
+var (
+	// accountIDPattern matches the account ids of Jira Cloud, either 24 hex
+	// digits or a prefixed UUID like 557058:f58131cb-b67d-43c7-b30d-6b58d40bd077.
+	accountIDPattern = regexp.MustCompile(`^(?:[0-9a-f]{24}|[0-9a-z]+:[0-9a-f]{8}(?:-[0-9a-f]{4}){3}-[0-9a-f]{12})$`)
+	// orderByPattern matches the ORDER BY clause ending a JQL query.
+	orderByPattern = regexp.MustCompile(`(?is)\s*\border\s+by\b.*$`)
+)
+
+type (
+	// User refers to a user by name on Jira Server and Data Center and by
+	// account id on Jira Cloud.
+	User struct {
+		Name      string
+		AccountID string
+	}
+
+	serverInfo struct {
+		BaseURL        string `json:"baseUrl"`
+		DeploymentType string `json:"deploymentType"`
+	}
+
+	jqlSearchRequest struct {
+		Query         string   `json:"jql"`
+		MaxResults    int      `json:"maxResults"`
+		Fields        []string `json:"fields"`
+		NextPageToken string   `json:"nextPageToken,omitempty"`
+	}
+
+	jqlSearchResult struct {
+		Issues        []domain.Issue `json:"issues"`
+		NextPageToken string         `json:"nextPageToken"`
+		IsLast        bool           `json:"isLast"`
+	}
+)
+
+// MarshalJSON refers to the user by account id when it has one, and by name
+// otherwise.
+func (u User) MarshalJSON() ([]byte, error) {
+	if u.AccountID != "" {
+		return json.Marshal(map[string]string{"accountId": u.AccountID})
+	}
+	return json.Marshal(map[string]string{"name": u.Name})
+}
+
+func validateDeployment(deployment string) error {
+	switch deployment {
+	case "", DeploymentAuto, DeploymentServer, DeploymentCloud:
+		return nil
+	}
+	return fmt.Errorf("unknown deployment %q, use %s, %s or %s", deployment, DeploymentAuto, DeploymentServer, DeploymentCloud)
+}
+
+// DetectDeployment reads from the server info of the instance whether it is
+// Jira Cloud or Jira Server/Data Center, unless Config.Deployment says so,
+// and returns the deployment the client talks to. Until the deployment is
+// detected, instances hosted under atlassian.net or reached through the
+// OAuth 2.0 API at api.atlassian.com are taken for Jira Cloud.
+// The base url of the site is kept for BrowseURL.
+func (c *Client) DetectDeployment() (string, error) {
+	if c.config.Deployment != "" && c.config.Deployment != DeploymentAuto {
+		return c.config.Deployment, nil
+	}
+
+	request, err := c.newRequest(http.MethodGet, "/rest/api/2/serverInfo", nil)
+	if err != nil {
+		return "", err
+	}
+	var info serverInfo
+	if err := c.sendRequest(request, &info); err != nil {
+		return "", fmt.Errorf("cannot read the server info : %w", err)
+	}
+
+	if info.BaseURL != "" {
+		c.baseURL.Store(strings.TrimSuffix(info.BaseURL, "/"))
+	}
+	deployment := DeploymentServer
+	if strings.EqualFold(info.DeploymentType, "Cloud") {
+		deployment = DeploymentCloud
+	}
+	c.deployment.Store(deployment)
+	return deployment, nil
+}
+
+// isCloud tells whether the instance is hosted on Jira Cloud, see
+// DetectDeployment.
+func (c *Client) isCloud() bool {
+	deployment := c.config.Deployment
+	if deployment == "" || deployment == DeploymentAuto {
+		deployment, _ = c.deployment.Load().(string)
+	}
+	if deployment != "" {
+		return deployment == DeploymentCloud
+	}
+	// the OAuth 2.0 API, https://api.atlassian.com/ex/jira/<cloud id>, only
+	// serves Jira Cloud
+	u, err := url.Parse(c.config.Host)
+	return err == nil && (strings.HasSuffix(u.Hostname(), ".atlassian.net") || u.Hostname() == "api.atlassian.com")
+}
+
+// apiPath sends the requests to the REST API v2 to v3 on Jira Cloud. Both
+// have the same resources, but v3 takes users by account id and rich text as
+// Atlassian Document Format documents.
+func (c *Client) apiPath(path string) string {
+	if strings.HasPrefix(path, "/rest/api/2/") && c.isCloud() {
+		return "/rest/api/3/" + strings.TrimPrefix(path, "/rest/api/2/")
+	}
+	return path
+}
+
+// richText converts text written in format to the rich text of the API in
+// use: wiki markup for the REST API v2 and an Atlassian Document Format
+// document, nil when text is empty, for v3.
+func (c *Client) richText(text, format string) interface{} {
+	if !c.isCloud() {
+		return markup.ToWiki(text, format)
+	}
+	if text == "" {
+		return nil
+	}
+	return markup.ToADF(text, format)
+}
+
+// updateField returns the field id with, on Jira Cloud, the schema
+// cloudFieldValue needs to convert its values.
+func (c *Client) updateField(id string) (Field, error) {
+	if !c.isCloud() {
+		return Field{ID: id}, nil
+	}
+	fields, err := c.lookupField(id, false)
+	if err != nil {
+		return Field{}, err
+	}
+	for _, field := range fields {
+		if field.ID == id {
+			return field, nil
+		}
+	}
+	return Field{ID: id}, nil
+}
+
+// operationRichText are the properties of the values of the operations on
+// a field that hold rich text, e.g. "comment": [{"add": {"body": "text"}}].
+var operationRichText = map[string]string{
+	"comment": "body",
+	"worklog": "comment",
+}
+
+// cloudOperations converts the values of the edit operations in update like
+// cloudFieldValue converts the values of fields.
+func (c *Client) cloudOperations(update map[string][]FieldOperation) (map[string][]FieldOperation, error) {
+	if !c.isCloud() || len(update) == 0 {
+		return update, nil
+	}
+	converted := make(map[string][]FieldOperation, len(update))
+	for id, operations := range update {
+		field, err := c.updateField(id)
+		if err != nil {
+			return nil, err
+		}
+		converted[id] = make([]FieldOperation, len(operations))
+		for i, operation := range operations {
+			converted[id][i] = FieldOperation{}
+			for verb, value := range operation {
+				if converted[id][i][verb], err = c.cloudOperationValue(field, value); err != nil {
+					return nil, fmt.Errorf("invalid %s operation on %s: %w", verb, id, err)
+				}
+			}
+		}
+	}
+	return converted, nil
+}
+
+func (c *Client) cloudOperationValue(field Field, value interface{}) (interface{}, error) {
+	property, ok := operationRichText[field.ID]
+	if !ok {
+		return c.cloudFieldValue(field, value)
+	}
+	object, ok := value.(map[string]interface{})
+	text, isText := object[property].(string)
+	if !ok || !isText {
+		return value, nil
+	}
+	converted := make(map[string]interface{}, len(object))
+	for k, v := range object {
+		converted[k] = v
+	}
+	converted[property] = c.richText(text, markup.Wiki)
+	return converted, nil
+}
+
+// cloudFieldValue converts the user names and texts a flow sets fields to, as
+// it would on Jira Server/Data Center, to the account ids and documents the
+// REST API v3 expects. Other values are returned as is.
+func (c *Client) cloudFieldValue(field Field, value interface{}) (interface{}, error) {
+	switch {
+	case !c.isCloud():
+		return value, nil
+	case field.Schema.Type == "user" || field.Schema.Items == "user":
+		if items, ok := value.([]interface{}); ok {
+			converted := make([]interface{}, len(items))
+			for i, item := range items {
+				v, err := c.cloudFieldValue(field, item)
+				if err != nil {
+					return nil, err
+				}
+				converted[i] = v
+			}
+			return converted, nil
+		}
+		name, ok := value.(string)
+		if object, isObject := value.(map[string]interface{}); isObject && object["accountId"] == nil {
+			name, ok = object["name"].(string)
+		}
+		if ok {
+			user, err := c.userRef(name, "")
+			if err != nil {
+				return nil, err
+			}
+			if user.AccountID == "" {
+				return nil, nil
+			}
+			return map[string]interface{}{"accountId": user.AccountID}, nil
+		}
+	case field.ID == "description" || field.ID == "environment" || strings.HasSuffix(field.Schema.Custom, ":textarea"):
+		if text, ok := value.(string); ok {
+			return markup.ToADF(text, markup.Wiki), nil
+		}
+	}
+	return value, nil
+}
+
+// SearchIssuesPage returns the page of the issues matching query that
+// pageToken, the NextPageToken of the previous page, points to. Only Jira
+// Cloud pages searches by token.
+func (c *Client) SearchIssuesPage(query, pageToken string, maxResults int) (SearchResult, error) {
+	if !c.isCloud() {
+		return SearchResult{}, fmt.Errorf("paging searches by token is %w on Jira Server and Data Center, use a start index", ErrNotSupported)
+	}
+	page, err := c.searchJQL(query, pageToken, maxResults, searchFields)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("query='%s' : %w", query, err)
+	}
+	return c.searchResult(query, 0, maxResults, page)
+}
+
+// searchCloud searches through the token paginated search of Jira Cloud,
+// which cannot start at an offset: the issues before startIndex are skipped
+// page by page.
+func (c *Client) searchCloud(query string, startIndex, maxResults int) (SearchResult, error) {
+	pageToken := ""
+	for skipped := 0; skipped < startIndex; {
+		page, err := c.searchJQL(query, pageToken, startIndex-skipped, []string{"id"})
+		if err != nil {
+			return SearchResult{}, fmt.Errorf("query='%s' : %w", query, err)
+		}
+		skipped += len(page.Issues)
+		if page.IsLast || page.NextPageToken == "" || len(page.Issues) == 0 {
+			return SearchResult{StartIndex: startIndex, MaxResults: maxResults, TotalResults: skipped}, nil
+		}
+		pageToken = page.NextPageToken
+	}
+
+	page, err := c.searchJQL(query, pageToken, maxResults, searchFields)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("query='%s' : %w", query, err)
+	}
+	return c.searchResult(query, startIndex, maxResults, page)
+}
+
+// searchResult returns page as a SearchResult. The total is only known on
+// the last page, it is estimated by Jira otherwise.
+func (c *Client) searchResult(query string, startIndex, maxResults int, page jqlSearchResult) (SearchResult, error) {
+	result := SearchResult{
+		StartIndex:    startIndex,
+		MaxResults:    maxResults,
+		TotalResults:  startIndex + len(page.Issues),
+		Issues:        page.Issues,
+		NextPageToken: page.NextPageToken,
+	}
+	if page.IsLast || page.NextPageToken == "" {
+		result.NextPageToken = ""
+		return result, nil
+	}
+
+	count, err := c.approximateCount(query)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("query='%s' : %w", query, err)
+	}
+	if count > result.TotalResults {
+		result.TotalResults = count
+	}
+	return result, nil
+}
+
+func (c *Client) searchJQL(query, pageToken string, maxResults int, fields []string) (jqlSearchResult, error) {
+	var page jqlSearchResult
+	b, err := json.Marshal(jqlSearchRequest{Query: query, MaxResults: maxResults, Fields: fields, NextPageToken: pageToken})
+	if err != nil {
+		return page, err
+	}
+	request, err := c.newRequest(http.MethodPost, jqlSearchPath, b)
+	if err != nil {
+		return page, err
+	}
+	err = c.sendRequest(markIdempotent(request), &page)
+	return page, err
+}
+
+// approximateCount returns the estimated number of issues matching query.
+func (c *Client) approximateCount(query string) (int, error) {
+	// the count does not depend on the order, which it does not accept
+	b, err := json.Marshal(map[string]string{"jql": orderByPattern.ReplaceAllString(query, "")})
+	if err != nil {
+		return 0, err
+	}
+	request, err := c.newRequest(http.MethodPost, "/rest/api/3/search/approximate-count", b)
+	if err != nil {
+		return 0, err
+	}
+	var count struct {
+		Count int `json:"count"`
+	}
+	err = c.sendRequest(markIdempotent(request), &count)
+	return count.Count, err
+}
@@ -0,0 +1,318 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cloudRequest is a request received by cloudServer.
+type cloudRequest struct {
+	Method, Path string
+	Body         map[string]interface{}
+}
+
+// cloudServer stands in for a Jira Cloud instance answering each path with
+// the JSON in responses, and records the requests it receives.
+func cloudServer(t *testing.T, responses map[string]string) (*Client, *[]cloudRequest) {
+	var requests []cloudRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := cloudRequest{Method: r.Method, Path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&request.Body)
+		requests = append(requests, request)
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL, Deployment: DeploymentCloud}), &requests
+}
+
+func TestDetectDeployment(t *testing.T) {
+	for deploymentType, expected := range map[string]string{"Cloud": DeploymentCloud, "Server": DeploymentServer, "DataCenter": DeploymentServer} {
+		t.Run(deploymentType, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/rest/api/2/serverInfo", r.URL.Path)
+				w.Write([]byte(`{"version": "1001.0.0", "deploymentType": "` + deploymentType + `"}`))
+			}))
+			t.Cleanup(server.Close)
+			c := newTestClient(t, Config{Host: server.URL})
+
+			deployment, err := c.DetectDeployment()
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, deployment)
+			assert.Equal(t, expected == DeploymentCloud, c.isCloud())
+		})
+	}
+}
+
+func TestBrowseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"baseUrl": "https://example.atlassian.net/", "deploymentType": "Cloud"}`))
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL + "/ex/jira/11223344-a1b2-3b33-c444-def123456789"})
+	assert.Equal(t, c.Host(), c.BrowseURL(), "the site is not known before the detection")
+
+	_, err := c.DetectDeployment()
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.atlassian.net", c.BrowseURL())
+	assert.Equal(t, "https://jira.example.com", newTestClient(t, Config{Host: server.URL, BrowseURL: "https://jira.example.com/"}).BrowseURL())
+}
+
+func TestDeploymentWithoutDetection(t *testing.T) {
+	c := newTestClient(t, Config{Host: "https://example.atlassian.net", Deployment: DeploymentServer},
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request: %s", r.URL)
+			return nil, errors.New("unexpected request")
+		})}))
+
+	deployment, err := c.DetectDeployment()
+
+	require.NoError(t, err)
+	assert.Equal(t, DeploymentServer, deployment)
+	assert.False(t, c.isCloud())
+	assert.True(t, newTestClient(t, Config{Host: "https://example.atlassian.net"}).isCloud())
+	assert.True(t, newTestClient(t, Config{Host: "https://api.atlassian.com/ex/jira/11223344-a1b2-3b33-c444-def123456789"}).isCloud())
+	assert.False(t, newTestClient(t, Config{Host: "https://jira.example.com"}).isCloud())
+
+	_, err = New(Config{Host: "https://jira.example.com", Deployment: "onprem"})
+	assert.EqualError(t, err, `unknown deployment "onprem", use auto, server or cloud`)
+}
+
+func TestCreateIssueOnCloud(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/user/search": `[{"accountId": "5b10a2844c20165700ede21f", "emailAddress": "jane.doe@example.com"}]`,
+		"/rest/api/3/issue/":      `{"key": "FLYTE-1"}`,
+	})
+
+	_, err := c.CreateIssue(NewIssue{
+		Project:     "FLYTE",
+		IssueType:   "Bug",
+		Summary:     "summary",
+		Description: "some **bold** text",
+		Format:      "markdown",
+		Reporter:    "jane.doe@example.com",
+		Assignee:    "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077",
+		Environment: "production",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, *requests, 4)
+	assert.Equal(t, "/rest/api/3/issue/createmeta/FLYTE/issuetypes", (*requests)[1].Path)
+	fields := (*requests)[3].Body["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"accountId": "5b10a2844c20165700ede21f"}, fields["reporter"])
+	assert.Equal(t, map[string]interface{}{"accountId": "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077"}, fields["assignee"])
+	assert.Equal(t, map[string]interface{}{"type": "doc", "version": 1.0, "content": []interface{}{
+		map[string]interface{}{"type": "paragraph", "content": []interface{}{
+			map[string]interface{}{"type": "text", "text": "some "},
+			map[string]interface{}{"type": "text", "text": "bold", "marks": []interface{}{map[string]interface{}{"type": "strong"}}},
+			map[string]interface{}{"type": "text", "text": " text"},
+		}},
+	}}, fields["description"])
+	assert.Equal(t, "doc", fields["environment"].(map[string]interface{})["type"])
+}
+
+func TestCreateIssueOnCloudWithUnknownReporter(t *testing.T) {
+	c, _ := cloudServer(t, map[string]string{"/rest/api/3/user/search": `[]`})
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Bug", Summary: "summary", Reporter: "nobody"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Equal(t, map[string]string{"reporter": `user not found: no user matches "nobody"`}, validationErr.FieldErrors)
+}
+
+func TestAssignIssueOnCloud(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/user/assignable/search": `[{"accountId": "5b10a2844c20165700ede21f", "emailAddress": "jane.doe@example.com"}]`,
+		"/rest/api/3/issue/FLYTE-1/assignee": `{}`,
+	})
+
+	require.NoError(t, c.AssignIssue("FLYTE-1", "jane.doe@example.com"))
+	require.NoError(t, c.AssignIssue("FLYTE-1", ""))
+
+	require.Len(t, *requests, 3)
+	assert.Equal(t, map[string]interface{}{"accountId": "5b10a2844c20165700ede21f"}, (*requests)[1].Body)
+	assert.Equal(t, map[string]interface{}{"accountId": nil}, (*requests)[2].Body)
+}
+
+func TestAddCommentOnCloud(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/issue/FLYTE-1/comment": `{"id": "10001", "body": {"type": "doc", "version": 1, "content": [
+			{"type": "paragraph", "content": [{"type": "text", "text": "done"}]}]}}`,
+	})
+
+	comment, err := c.CommentIssue("FLYTE-1", "done")
+
+	require.NoError(t, err)
+	assert.Equal(t, "done", comment.Body)
+	assert.Equal(t, "doc", (*requests)[0].Body["body"].(map[string]interface{})["type"])
+}
+
+func TestEditCommentOnCloud(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/issue/FLYTE-1/comment/10001": `{"id": "10001", "body": {"type": "doc", "version": 1, "content": [
+			{"type": "paragraph", "content": [{"type": "text", "text": "done", "marks": [{"type": "strong"}]}]}]}}`,
+	})
+
+	comment, err := c.EditComment("FLYTE-1", "10001", "**done**", markup.Markdown)
+
+	require.NoError(t, err)
+	assert.Equal(t, "done", comment.Body)
+	require.Len(t, *requests, 1)
+	assert.Equal(t, http.MethodPut, (*requests)[0].Method)
+	assert.Equal(t, map[string]interface{}{"type": "doc", "version": 1.0, "content": []interface{}{
+		map[string]interface{}{"type": "paragraph", "content": []interface{}{
+			map[string]interface{}{"type": "text", "text": "done", "marks": []interface{}{map[string]interface{}{"type": "strong"}}},
+		}},
+	}}, (*requests)[0].Body["body"])
+}
+
+func TestGetIssueInfoOnCloud(t *testing.T) {
+	c, _ := cloudServer(t, map[string]string{
+		"/rest/api/3/issue/FLYTE-1": `{"key": "FLYTE-1", "fields": {"summary": "summary", "description": {"type": "doc", "version": 1,
+			"content": [{"type": "paragraph", "content": [{"type": "text", "text": "first"}]}, {"type": "paragraph", "content": [{"type": "text", "text": "second"}]}]}}}`,
+	})
+
+	issue, err := c.GetIssueInfo("FLYTE-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "summary", issue.Fields.Summary)
+	assert.Equal(t, "first\n\nsecond", issue.Fields.Description)
+}
+
+func TestUpdateIssueOnCloud(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/field": `[{"id": "summary", "schema": {"type": "string", "system": "summary"}},
+			{"id": "description", "schema": {"type": "string", "system": "description"}},
+			{"id": "assignee", "schema": {"type": "user", "system": "assignee"}},
+			{"id": "comment", "schema": {"type": "comments-page", "system": "comment"}},
+			{"id": "customfield_10010", "schema": {"type": "array", "items": "user"}}]`,
+		"/rest/api/3/user/search":   `[{"accountId": "5b10a2844c20165700ede21f", "emailAddress": "jane.doe@example.com"}]`,
+		"/rest/api/3/issue/FLYTE-1": `{}`,
+	})
+
+	err := c.UpdateIssue("FLYTE-1", IssueUpdate{
+		Fields: map[string]interface{}{"description": "new description", "summary": "new summary", "assignee": "jane.doe@example.com"},
+		Update: map[string][]FieldOperation{
+			"comment":           {{"add": map[string]interface{}{"body": "reassigned"}}},
+			"customfield_10010": {{"add": "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077"}},
+		},
+	})
+
+	require.NoError(t, err)
+	request := (*requests)[len(*requests)-1]
+	require.Equal(t, "/rest/api/3/issue/FLYTE-1", request.Path)
+	fields := request.Body["fields"].(map[string]interface{})
+	assert.Equal(t, "new summary", fields["summary"])
+	assert.Equal(t, "doc", fields["description"].(map[string]interface{})["type"])
+	assert.Equal(t, map[string]interface{}{"accountId": "5b10a2844c20165700ede21f"}, fields["assignee"])
+	update := request.Body["update"].(map[string]interface{})
+	comment := update["comment"].([]interface{})[0].(map[string]interface{})["add"].(map[string]interface{})
+	assert.Equal(t, "doc", comment["body"].(map[string]interface{})["type"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"add": map[string]interface{}{"accountId": "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077"}}}, update["customfield_10010"])
+}
+
+func TestSearchIssuesOnCloud(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		switch {
+		case r.URL.Path == "/rest/api/3/search/approximate-count":
+			w.Write([]byte(`{"count": 42}`))
+		case r.URL.Path != "/rest/api/3/search/jql":
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		case body["nextPageToken"] == nil:
+			w.Write([]byte(`{"issues": [{"id": "1"}, {"id": "2"}], "nextPageToken": "page-2"}`))
+		default:
+			w.Write([]byte(`{"issues": [{"key": "FLYTE-3"}, {"key": "FLYTE-4"}], "nextPageToken": "page-3"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL, Deployment: DeploymentCloud})
+
+	result, err := c.SearchIssues("project = FLYTE ORDER BY created DESC", 2, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.StartIndex)
+	assert.Equal(t, 42, result.TotalResults)
+	assert.Equal(t, "page-3", result.NextPageToken)
+	require.Len(t, result.Issues, 2)
+	assert.Equal(t, "FLYTE-3", result.Issues[0].Key)
+	require.Len(t, requests, 3)
+	assert.Equal(t, []interface{}{"id"}, requests[0]["fields"])
+	assert.Equal(t, 2.0, requests[0]["maxResults"])
+	assert.Equal(t, "page-2", requests[1]["nextPageToken"])
+	assert.Equal(t, map[string]interface{}{"jql": "project = FLYTE"}, requests[2])
+}
+
+func TestSearchIssuesOnCloudLastPage(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/search/jql": `{"issues": [{"key": "FLYTE-1"}], "isLast": true}`,
+	})
+
+	result, err := c.SearchIssuesPage("project = FLYTE", "page-2", 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TotalResults)
+	assert.Empty(t, result.NextPageToken)
+	assert.Len(t, *requests, 1)
+}
+
+func TestSearchIssuesPageOnServer(t *testing.T) {
+	c := newTestClient(t, Config{Host: "https://jira.example.com", Deployment: DeploymentServer})
+
+	_, err := c.SearchIssuesPage("project = FLYTE", "page-2", 10)
+
+	assert.True(t, errors.Is(err, ErrNotSupported), "%v", err)
+}
+
+func TestCloneIssueOnCloud(t *testing.T) {
+	description := `{"type": "doc", "version": 1, "content": [{"type": "rule"}]}`
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/issue/FLYTE-1": `{"key": "FLYTE-1", "fields": {"summary": "summary", "description": ` + description + `,
+			"issuetype": {"name": "Bug"}, "project": {"key": "FLYTE"}}}`,
+		"/rest/api/3/issue/":    `{"key": "FLYTE-2"}`,
+		"/rest/api/3/issueLink": `{}`,
+	})
+
+	result, err := c.CloneIssue("FLYTE-1", CloneOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "FLYTE-2", result.Key)
+	for _, request := range *requests {
+		if request.Method == http.MethodPost && request.Path == "/rest/api/3/issue/" {
+			fields := request.Body["fields"].(map[string]interface{})
+			assert.Equal(t, []interface{}{map[string]interface{}{"type": "rule"}}, fields["description"].(map[string]interface{})["content"])
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"net/http"
+)
+
+// CommentsResult is a page of the comments of an issue.
+type CommentsResult struct {
+	StartIndex   int              `json:"startAt"`
+	MaxResults   int              `json:"maxResults"`
+	TotalResults int              `json:"total"`
+	Comments     []domain.Comment `json:"comments"`
+}
+
+// ListComments returns a page of the comments of the issue issueId, oldest
+// first unless newestFirst is set.
+func (c *Client) ListComments(issueId string, startIndex, maxResults int, newestFirst bool) (CommentsResult, error) {
+	var result CommentsResult
+	orderBy := "created"
+	if newestFirst {
+		orderBy = "-created"
+	}
+	path := fmt.Sprintf("/rest/api/2/issue/%s/comment?startAt=%d&maxResults=%d&orderBy=%s", issueId, startIndex, maxResults, orderBy)
+	request, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return result, err
+	}
+
+	if err := c.sendRequest(request, &result); err != nil {
+		return CommentsResult{}, fmt.Errorf("issueId=%s : %w", issueId, err)
+	}
+	return result, nil
+}
+
+// EditComment replaces the body of the comment commentId of the issue issueId
+// with body, written in format, and returns the updated comment.
+func (c *Client) EditComment(issueId, commentId, body, format string) (domain.Comment, error) {
+	var updated domain.Comment
+	if err := markup.CheckFormat(format); err != nil {
+		return updated, fmt.Errorf("issueId=%s commentId=%s : %w", issueId, commentId, err)
+	}
+	b, err := json.Marshal(Comment{Body: c.richText(body, format)})
+	if err != nil {
+		return updated, err
+	}
+
+	path := fmt.Sprintf("/rest/api/2/issue/%s/comment/%s", issueId, commentId)
+	request, err := c.newRequest(http.MethodPut, path, b)
+	if err != nil {
+		return updated, err
+	}
+
+	if err := c.sendRequest(request, &updated); err != nil {
+		return domain.Comment{}, fmt.Errorf("issueId=%s commentId=%s : %w", issueId, commentId, err)
+	}
+	return updated, nil
+}
+
+// DeleteComment deletes the comment commentId of the issue issueId.
+func (c *Client) DeleteComment(issueId, commentId string) error {
+	path := fmt.Sprintf("/rest/api/2/issue/%s/comment/%s", issueId, commentId)
+	request, err := c.newRequest(http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := c.sendRequestWithoutResp(request); err != nil {
+		return fmt.Errorf("issueId=%s commentId=%s : %w", issueId, commentId, err)
+	}
+	return nil
+}
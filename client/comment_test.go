@@ -0,0 +1,78 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCommentIssueReturnsComment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "10001", "author": {"name": "flyte"}, "body": "hello", "created": "2020-01-01T10:00:00.000+0000"}`))
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	comment, err := c.CommentIssue("TEST-1", "hello")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.Comment{
+		ID:      "10001",
+		Author:  domain.User{Name: "flyte"},
+		Body:    "hello",
+		Created: "2020-01-01T10:00:00.000+0000",
+	}, comment)
+}
+
+func TestListComments(t *testing.T) {
+	var requestURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.URL.RequestURI()
+		w.Write([]byte(`{"startAt": 10, "maxResults": 5, "total": 11, "comments": [{"id": "10001", "body": "hello"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	result, err := c.ListComments("TEST-1", 10, 5, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, "/rest/api/2/issue/TEST-1/comment?startAt=10&maxResults=5&orderBy=created", requestURI)
+	assert.Equal(t, CommentsResult{
+		StartIndex:   10,
+		MaxResults:   5,
+		TotalResults: 11,
+		Comments:     []domain.Comment{{ID: "10001", Body: "hello"}},
+	}, result)
+}
+
+func TestDeleteCommentFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	err := c.DeleteComment("TEST-1", "10001")
+
+	assert.EqualError(t, err, "issueId=TEST-1 commentId=10001 : statusCode=404")
+}
@@ -0,0 +1,433 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// createMetaTTL is how long the create metadata of a project is used
+	// before it is fetched again.
+	createMetaTTL = time.Hour
+	// maxSummaryLength is the longest summary Jira accepts.
+	maxSummaryLength = 255
+)
+
+type (
+	// ValidationError is returned when an issue does not match the create
+	// metadata of its project, so it is not sent to Jira.
+	ValidationError struct {
+		Project   string
+		IssueType string
+		// FieldErrors maps the invalid fields to what is wrong with them.
+		FieldErrors map[string]string
+	}
+
+	// FieldMeta describes a field of the create screen of an issue type.
+	FieldMeta struct {
+		FieldID         string         `json:"fieldId"`
+		Name            string         `json:"name"`
+		Required        bool           `json:"required"`
+		HasDefaultValue bool           `json:"hasDefaultValue"`
+		Schema          FieldSchema    `json:"schema"`
+		AllowedValues   []AllowedValue `json:"allowedValues"`
+	}
+
+	// AllowedValue is one of the values a field such as the priority or a
+	// select list accepts.
+	AllowedValue struct {
+		ID    string `json:"id"`
+		Name  string `json:"name,omitempty"`
+		Value string `json:"value,omitempty"`
+		Key   string `json:"key,omitempty"`
+	}
+
+	issueTypeMeta struct {
+		ID      string               `json:"id"`
+		Name    string               `json:"name"`
+		Subtask bool                 `json:"subtask"`
+		Fields  map[string]FieldMeta `json:"fields"`
+	}
+
+	projectMeta struct {
+		issueTypes []issueTypeMeta
+		loaded     time.Time
+	}
+
+	createMetaCache struct {
+		mu       sync.Mutex
+		projects map[string]*projectMeta
+	}
+
+	// page is a page of the paginated createmeta responses.
+	page struct {
+		Values json.RawMessage `json:"values"`
+		IsLast bool            `json:"isLast"`
+	}
+)
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	details := make([]string, len(fields))
+	for i, field := range fields {
+		details[i] = fmt.Sprintf("%s: %s", field, e.FieldErrors[field])
+	}
+	if e.Project == "" {
+		return fmt.Sprintf("issue is not valid : %s", strings.Join(details, "; "))
+	}
+	return fmt.Sprintf("issue is not valid for project %s : %s", e.Project, strings.Join(details, "; "))
+}
+
+// validateCreate checks the fields of a new issue against the create
+// metadata of its project: the issue type must exist, required fields must
+// be set and fields with a fixed set of values must use one of them. When
+// the metadata cannot be read the issue is left for Jira to validate.
+func (c *Client) validateCreate(issueFields interface{}) error {
+	b, err := json.Marshal(issueFields)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	project := valueKey(fields["project"], "key")
+	issueTypeName := valueKey(fields["issuetype"], "name")
+	invalid := &ValidationError{Project: project, IssueType: issueTypeName, FieldErrors: map[string]string{}}
+	if summary, _ := fields["summary"].(string); len([]rune(summary)) > maxSummaryLength {
+		invalid.FieldErrors["summary"] = fmt.Sprintf("must be at most %d characters long, it has %d", maxSummaryLength, len([]rune(summary)))
+	}
+
+	issueTypes, err := c.projectIssueTypes(project)
+	if err != nil {
+		log.Printf("cannot validate issue for project %s, create metadata not available: %v", project, err)
+		return invalid.orNil()
+	}
+	if issueTypes == nil {
+		invalid.FieldErrors["project"] = fmt.Sprintf("project %s does not exist or you cannot create issues in it", project)
+		return invalid
+	}
+
+	issueType, ok := findIssueType(issueTypes, issueTypeName)
+	if !ok {
+		names := make([]string, len(issueTypes))
+		for i, t := range issueTypes {
+			names[i] = t.Name
+		}
+		invalid.FieldErrors["issuetype"] = fmt.Sprintf("%q is not an issue type of project %s, use one of: %s", issueTypeName, project, strings.Join(names, ", "))
+		return invalid
+	}
+	if issueType.Fields == nil {
+		if issueType.Fields, err = c.issueTypeFields(project, issueType.ID); err != nil {
+			log.Printf("cannot validate issue for project %s, create metadata not available: %v", project, err)
+			return invalid.orNil()
+		}
+		c.createMeta.storeFields(project, issueType)
+	}
+
+	for id, meta := range issueType.Fields {
+		value, set := fields[id]
+		if set && !isEmptyValue(value) {
+			if err := checkAllowedValue(meta, value); err != nil {
+				invalid.FieldErrors[id] = err.Error()
+			}
+		} else if meta.Required && !meta.HasDefaultValue {
+			invalid.FieldErrors[id] = fmt.Sprintf("%s is required", meta.Name)
+		}
+	}
+	for id, value := range fields {
+		if id == "project" || id == "issuetype" {
+			// already checked above, whether or not the screen lists them
+			continue
+		}
+		if _, ok := issueType.Fields[id]; !ok && !isEmptyValue(value) {
+			invalid.FieldErrors[id] = fmt.Sprintf("cannot be set, it is not on the create screen of %s issues in project %s", issueType.Name, project)
+		}
+	}
+	return invalid.orNil()
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.FieldErrors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// projectIssueTypes returns the issue types of project from the cache or
+// from Jira, nil when the project does not exist. Jira 8.4 and later list
+// them, without their fields, through createmeta/{project}/issuetypes; older
+// versions only support the createmeta endpoint returning everything at once.
+func (c *Client) projectIssueTypes(project string) ([]issueTypeMeta, error) {
+	if issueTypes, ok := c.createMeta.get(project); ok {
+		return issueTypes, nil
+	}
+
+	var issueTypes []issueTypeMeta
+	path := fmt.Sprintf("/rest/api/2/issue/createmeta/%s/issuetypes", url.PathEscape(project))
+	err := c.getPages(path, func(values json.RawMessage) error {
+		var types []issueTypeMeta
+		err := json.Unmarshal(values, &types)
+		issueTypes = append(issueTypes, types...)
+		return err
+	})
+
+	var jiraErr *JiraError
+	legacy := errors.As(err, &jiraErr) && jiraErr.StatusCode == http.StatusNotFound
+	if legacy {
+		issueTypes, err = c.legacyProjectIssueTypes(project)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if issueTypes == nil && !legacy {
+		issueTypes = []issueTypeMeta{}
+	}
+
+	c.createMeta.put(project, issueTypes)
+	return issueTypes, nil
+}
+
+func (c *Client) legacyProjectIssueTypes(project string) ([]issueTypeMeta, error) {
+	var meta struct {
+		Projects []struct {
+			IssueTypes []issueTypeMeta `json:"issuetypes"`
+		} `json:"projects"`
+	}
+	path := "/rest/api/2/issue/createmeta?expand=projects.issuetypes.fields&projectKeys=" + url.QueryEscape(project)
+	request, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.sendRequest(request, &meta); err != nil {
+		return nil, err
+	}
+	if len(meta.Projects) == 0 {
+		return nil, nil
+	}
+
+	issueTypes := meta.Projects[0].IssueTypes
+	for _, issueType := range issueTypes {
+		for id, field := range issueType.Fields {
+			field.FieldID = id
+			issueType.Fields[id] = field
+		}
+	}
+	if issueTypes == nil {
+		issueTypes = []issueTypeMeta{}
+	}
+	return issueTypes, nil
+}
+
+func (c *Client) issueTypeFields(project, issueTypeID string) (map[string]FieldMeta, error) {
+	fields := map[string]FieldMeta{}
+	path := fmt.Sprintf("/rest/api/2/issue/createmeta/%s/issuetypes/%s", url.PathEscape(project), url.PathEscape(issueTypeID))
+	err := c.getPages(path, func(values json.RawMessage) error {
+		var page []FieldMeta
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		for _, field := range page {
+			fields[field.FieldID] = field
+		}
+		return nil
+	})
+	return fields, err
+}
+
+// getPages calls handle with the values of every page of a paginated
+// createmeta resource.
+func (c *Client) getPages(path string, handle func(values json.RawMessage) error) error {
+	for startAt := 0; ; {
+		request, err := c.newRequest(http.MethodGet, fmt.Sprintf("%s?startAt=%d&maxResults=100", path, startAt), nil)
+		if err != nil {
+			return err
+		}
+		var p page
+		if err := c.sendRequest(request, &p); err != nil {
+			return err
+		}
+		if err := handle(p.Values); err != nil {
+			return err
+		}
+
+		var values []json.RawMessage
+		json.Unmarshal(p.Values, &values)
+		if p.IsLast || len(values) == 0 {
+			return nil
+		}
+		startAt += len(values)
+	}
+}
+
+func (m *createMetaCache) get(project string) ([]issueTypeMeta, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	meta, ok := m.projects[strings.ToUpper(project)]
+	if !ok || time.Since(meta.loaded) > createMetaTTL {
+		return nil, false
+	}
+	if meta.issueTypes == nil {
+		return nil, true
+	}
+	// a copy, as storeFields may update the cached issue types
+	return append([]issueTypeMeta{}, meta.issueTypes...), true
+}
+
+func (m *createMetaCache) put(project string, issueTypes []issueTypeMeta) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.projects == nil {
+		m.projects = map[string]*projectMeta{}
+	}
+	m.projects[strings.ToUpper(project)] = &projectMeta{issueTypes: issueTypes, loaded: time.Now()}
+}
+
+// storeFields caches the fields of an issue type loaded after its project.
+func (m *createMetaCache) storeFields(project string, issueType issueTypeMeta) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if meta, ok := m.projects[strings.ToUpper(project)]; ok {
+		for i, t := range meta.issueTypes {
+			if t.ID == issueType.ID {
+				meta.issueTypes[i].Fields = issueType.Fields
+			}
+		}
+	}
+}
+
+func findIssueType(issueTypes []issueTypeMeta, name string) (issueTypeMeta, bool) {
+	for _, t := range issueTypes {
+		if strings.EqualFold(t.Name, name) || t.ID == name {
+			return t, true
+		}
+	}
+	return issueTypeMeta{}, false
+}
+
+// checkAllowedValue verifies that value, or each of its elements for
+// multi-value fields, is one of the allowed values of the field, if any.
+func checkAllowedValue(meta FieldMeta, value interface{}) error {
+	if len(meta.AllowedValues) == 0 {
+		return nil
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	for _, v := range values {
+		if !isAllowed(meta.AllowedValues, v) {
+			return fmt.Errorf("%s is not an allowed value for %s, use one of: %s", describeValue(v), meta.Name, strings.Join(allowedNames(meta.AllowedValues), ", "))
+		}
+	}
+	return nil
+}
+
+func isAllowed(allowed []AllowedValue, value interface{}) bool {
+	candidates := []string{valueKey(value, "id"), valueKey(value, "name"), valueKey(value, "value"), valueKey(value, "key")}
+	if s, ok := value.(string); ok {
+		candidates = []string{s}
+	}
+	for _, a := range allowed {
+		for _, candidate := range candidates {
+			if candidate == "" {
+				continue
+			}
+			if candidate == a.ID || strings.EqualFold(candidate, a.Name) || strings.EqualFold(candidate, a.Value) || strings.EqualFold(candidate, a.Key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allowedNames(allowed []AllowedValue) []string {
+	names := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		switch {
+		case a.Name != "":
+			names = append(names, a.Name)
+		case a.Value != "":
+			names = append(names, a.Value)
+		case a.Key != "":
+			names = append(names, a.Key)
+		default:
+			names = append(names, a.ID)
+		}
+	}
+	return names
+}
+
+func describeValue(value interface{}) string {
+	for _, key := range []string{"name", "value", "key", "id"} {
+		if v := valueKey(value, key); v != "" {
+			return fmt.Sprintf("%q", v)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// valueKey returns value[key] of a JSON object, or "".
+func valueKey(value interface{}, key string) string {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	s, _ := m[key].(string)
+	return s
+}
+
+// isEmptyValue tells whether a field value is left unset, as the create
+// requests carry empty values such as {"name": ""} for optional fields.
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, e := range v {
+			if !isEmptyValue(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var storyFields = []FieldMeta{
+	{FieldID: "project", Name: "Project", Required: true},
+	{FieldID: "issuetype", Name: "Issue Type", Required: true},
+	{FieldID: "summary", Name: "Summary", Required: true},
+	{FieldID: "description", Name: "Description", Required: true},
+	{FieldID: "reporter", Name: "Reporter", Required: true, HasDefaultValue: true},
+	{FieldID: "labels", Name: "Labels"},
+	{FieldID: "priority", Name: "Priority", AllowedValues: []AllowedValue{{ID: "1", Name: "High"}, {ID: "3", Name: "Low"}}},
+	{FieldID: "customfield_10003", Name: "Team", AllowedValues: []AllowedValue{{ID: "10100", Value: "Platform"}, {ID: "10101", Value: "Payments"}}},
+}
+
+// createMetaServer is a Jira stand-in serving the create metadata of the
+// FLYTE project, either through the paginated endpoints of Jira 8.4+ or
+// through the legacy createmeta endpoint.
+type createMetaServer struct {
+	mu       sync.Mutex
+	legacy   bool
+	requests []string
+	created  int
+}
+
+func (s *createMetaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+
+	encode := func(v interface{}) { json.NewEncoder(w).Encode(v) }
+	switch {
+	case r.Method == http.MethodPost:
+		s.created++
+		w.WriteHeader(http.StatusCreated)
+		encode(map[string]string{"key": "FLYTE-1"})
+	case r.URL.Path == "/rest/api/2/field":
+		encode(testFields)
+	case s.legacy && r.URL.Path == "/rest/api/2/issue/createmeta":
+		if r.URL.Query().Get("projectKeys") != "FLYTE" {
+			encode(map[string]interface{}{"projects": []interface{}{}})
+			return
+		}
+		fields := map[string]FieldMeta{}
+		for _, f := range storyFields {
+			// old versions only give the field id as the key
+			id := f.FieldID
+			f.FieldID = ""
+			fields[id] = f
+		}
+		encode(map[string]interface{}{"projects": []interface{}{map[string]interface{}{
+			"key":        "FLYTE",
+			"issuetypes": []interface{}{map[string]interface{}{"id": "2", "name": "Story", "fields": fields}},
+		}}})
+	case s.legacy:
+		w.WriteHeader(http.StatusNotFound)
+	case r.URL.Path == "/rest/api/2/issue/createmeta/FLYTE/issuetypes":
+		encode(map[string]interface{}{"values": []map[string]string{{"id": "1", "name": "Bug"}, {"id": "2", "name": "Story"}}, "isLast": true})
+	case r.URL.Path == "/rest/api/2/issue/createmeta/FLYTE/issuetypes/2":
+		// two pages, to exercise pagination
+		if r.URL.Query().Get("startAt") == "0" {
+			encode(map[string]interface{}{"values": storyFields[:4], "isLast": false})
+		} else {
+			encode(map[string]interface{}{"values": storyFields[4:], "isLast": true})
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		encode(map[string]interface{}{"errorMessages": []string{"No project could be found with key 'NOPE'."}})
+	}
+}
+
+func newCreateMetaClient(t *testing.T, legacy bool) (*Client, *createMetaServer) {
+	s := &createMetaServer{legacy: legacy}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL}), s
+}
+
+func TestCreateIssuePassesValidation(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		c, s := newCreateMetaClient(t, legacy)
+
+		for i := 0; i < 2; i++ {
+			_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "story", Summary: "summary", Description: "description", Priority: "high", CustomFields: map[string]interface{}{"Team": "Platform"}})
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, s.created)
+		metaRequests := 0
+		for _, r := range s.requests {
+			if strings.Contains(r, "createmeta") {
+				metaRequests++
+			}
+		}
+		if legacy {
+			assert.Equal(t, 2, metaRequests, "one 404 and one legacy createmeta request, then cached")
+		} else {
+			assert.Equal(t, 3, metaRequests, "issue types and two pages of fields, then cached")
+		}
+	}
+}
+
+func TestCreateIssueFailsValidation(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		c, s := newCreateMetaClient(t, legacy)
+
+		_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: strings.Repeat("x", 256), Priority: "Urgent", CustomFields: map[string]interface{}{"Team": "Growth"}})
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr), "%v", err)
+		assert.Equal(t, map[string]string{
+			"summary":           "must be at most 255 characters long, it has 256",
+			"description":       "Description is required",
+			"priority":          `"Urgent" is not an allowed value for Priority, use one of: High, Low`,
+			"customfield_10003": `"Growth" is not an allowed value for Team, use one of: Platform, Payments`,
+		}, validationErr.FieldErrors)
+		assert.Equal(t, 0, s.created)
+	}
+}
+
+func TestCreateIssueWithUnknownIssueType(t *testing.T) {
+	c, s := newCreateMetaClient(t, false)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Epic", Summary: "summary", Description: "description"})
+
+	assert.EqualError(t, err, `issueSummary='summary' : issue is not valid for project FLYTE : issuetype: "Epic" is not an issue type of project FLYTE, use one of: Bug, Story`)
+	assert.Equal(t, 0, s.created)
+}
+
+func TestCreateIssueWithFieldNotOnScreen(t *testing.T) {
+	c, _ := newCreateMetaClient(t, false)
+
+	_, err := c.CreateCustomIssue("FLYTE", "Story", "summary", "description", "", "", []string{"ops"})
+	require.NoError(t, err, "labels are on the screen")
+
+	_, err = c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", Description: "description", CustomFields: map[string]interface{}{"Story Points": 3}})
+	assert.EqualError(t, err, "issueSummary='summary' : issue is not valid for project FLYTE : customfield_10002: cannot be set, it is not on the create screen of Story issues in project FLYTE")
+}
+
+func TestCreateIssueInUnknownProject(t *testing.T) {
+	c, s := newCreateMetaClient(t, true)
+
+	_, err := c.CreateCustomIssue("NOPE", "Story", "summary", "description", "", "", nil)
+
+	assert.EqualError(t, err, "issueSummary='summary' : issue is not valid for project NOPE : project: project NOPE does not exist or you cannot create issues in it")
+	assert.Equal(t, 0, s.created)
+}
+
+func TestCreateIssueWithoutCreateMeta(t *testing.T) {
+	var created bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			created = true
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL})
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary"})
+
+	require.NoError(t, err)
+	assert.True(t, created, "the issue is left for Jira to validate")
+}
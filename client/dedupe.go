@@ -0,0 +1,176 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// dedupeLabelPrefix marks the labels holding deduplication keys.
+	dedupeLabelPrefix = "flyte-dedupe-"
+	// maxLabelLength is the longest label Jira accepts.
+	maxLabelLength = 255
+	// recentCreationTTL is how long created issues are remembered, to cover
+	// the delay until Jira's search index finds them.
+	recentCreationTTL = 10 * time.Minute
+)
+
+type (
+	// dedupeState serialises creations with the same project and
+	// deduplication key and remembers the issues created recently, keyed by
+	// both.
+	dedupeState struct {
+		mu     sync.Mutex
+		locks  map[string]*keyLock
+		recent map[string]recentCreation
+	}
+
+	keyLock struct {
+		sync.Mutex
+		holders int
+	}
+
+	recentCreation struct {
+		issue   CreateIssueAPIResponse
+		created time.Time
+	}
+)
+
+// dedupeLabel returns the label identifying the issue created for dedupeKey.
+func dedupeLabel(dedupeKey string) (string, error) {
+	key := strings.TrimSpace(dedupeKey)
+	if key == "" {
+		return "", errors.New("must not be empty")
+	}
+	if strings.IndexFunc(key, unicode.IsSpace) >= 0 {
+		return "", errors.New("must not contain spaces, it is stored as a label")
+	}
+	label := dedupeLabelPrefix + key
+	if len(label) > maxLabelLength {
+		return "", fmt.Errorf("must be at most %d characters long", maxLabelLength-len(dedupeLabelPrefix))
+	}
+	return label, nil
+}
+
+// CreateOnce calls create unless an issue was created for dedupeKey in the
+// project already, in which case that issue is returned and create is not
+// called. create is given the label it must add to the new issue so that it
+// is found next time. Calls with the same project and key are serialised, so
+// that concurrent retries of a flow create a single issue.
+func (c *Client) CreateOnce(project, dedupeKey string, create func(label string) (CreateIssueAPIResponse, error)) (existing *CreateIssueAPIResponse, err error) {
+	label, err := dedupeLabel(dedupeKey)
+	if err != nil {
+		return nil, &ValidationError{FieldErrors: map[string]string{"dedupeKey": err.Error()}}
+	}
+	project = strings.ToUpper(strings.TrimSpace(project))
+	if project == "" {
+		return nil, &ValidationError{FieldErrors: map[string]string{"project": "must not be empty"}}
+	}
+
+	// the same key may be used in several projects
+	key := project + "/" + label
+	unlock := c.dedupe.lock(key)
+	defer unlock()
+
+	if issue, ok := c.dedupe.recentlyCreated(key); ok {
+		return &issue, nil
+	}
+	jql := fmt.Sprintf("project = %s AND labels = %s ORDER BY created ASC", jqlString(project), jqlString(label))
+	result, err := c.SearchIssues(jql, 0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("dedupeKey=%s : %w", dedupeKey, err)
+	}
+	if len(result.Issues) > 0 {
+		issue := result.Issues[0]
+		return &CreateIssueAPIResponse{ID: issue.ID, Key: issue.Key, Self: issue.Self}, nil
+	}
+
+	issue, err := create(label)
+	if err != nil {
+		return nil, err
+	}
+	c.dedupe.remember(key, issue)
+	return nil, nil
+}
+
+func (d *dedupeState) lock(key string) (unlock func()) {
+	d.mu.Lock()
+	if d.locks == nil {
+		d.locks = map[string]*keyLock{}
+	}
+	l, ok := d.locks[key]
+	if !ok {
+		l = &keyLock{}
+		d.locks[key] = l
+	}
+	l.holders++
+	d.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		d.mu.Lock()
+		if l.holders--; l.holders == 0 {
+			delete(d.locks, key)
+		}
+		d.mu.Unlock()
+	}
+}
+
+func (d *dedupeState) recentlyCreated(key string) (CreateIssueAPIResponse, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r, ok := d.recent[key]
+	if !ok || time.Since(r.created) > recentCreationTTL {
+		return CreateIssueAPIResponse{}, false
+	}
+	return r.issue, true
+}
+
+func (d *dedupeState) remember(key string, issue CreateIssueAPIResponse) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.recent == nil {
+		d.recent = map[string]recentCreation{}
+	}
+	for l, r := range d.recent {
+		if time.Since(r.created) > recentCreationTTL {
+			delete(d.recent, l)
+		}
+	}
+	d.recent[key] = recentCreation{issue: issue, created: time.Now()}
+}
+
+// forget drops the issues issueKeys from the issues created recently, once
+// they are deleted.
+func (d *dedupeState) forget(issueKeys ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, r := range d.recent {
+		for _, issueKey := range issueKeys {
+			if r.issue.Key == issueKey {
+				delete(d.recent, key)
+			}
+		}
+	}
+}
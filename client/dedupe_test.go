@@ -0,0 +1,151 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// searchServer answers searches with issues and records the queries.
+func searchServer(t *testing.T, status int, issues string) (*Client, *[]string) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var search SearchRequestType
+		json.NewDecoder(r.Body).Decode(&search)
+		mu.Lock()
+		queries = append(queries, search.Query)
+		mu.Unlock()
+		w.WriteHeader(status)
+		w.Write([]byte(`{"issues": ` + issues + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL}), &queries
+}
+
+func TestCreateOnceCreatesNewIssue(t *testing.T) {
+	c, queries := searchServer(t, http.StatusOK, `[]`)
+	var labels []string
+	create := func(label string) (CreateIssueAPIResponse, error) {
+		labels = append(labels, label)
+		return CreateIssueAPIResponse{Key: "FLYTE-1"}, nil
+	}
+
+	existing, err := c.CreateOnce("FLYTE", "INC1234567", create)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.Equal(t, []string{"flyte-dedupe-INC1234567"}, labels)
+	assert.Equal(t, []string{`project = "FLYTE" AND labels = "flyte-dedupe-INC1234567" ORDER BY created ASC`}, *queries)
+
+	// the search index may not know the new issue yet
+	existing, err = c.CreateOnce("FLYTE", "INC1234567", create)
+	require.NoError(t, err)
+	assert.Equal(t, &CreateIssueAPIResponse{Key: "FLYTE-1"}, existing)
+	assert.Len(t, labels, 1)
+	assert.Len(t, *queries, 1)
+}
+
+func TestCreateOnceIsScopedToTheProject(t *testing.T) {
+	c, queries := searchServer(t, http.StatusOK, `[]`)
+	create := func(project string) func(label string) (CreateIssueAPIResponse, error) {
+		return func(label string) (CreateIssueAPIResponse, error) {
+			return CreateIssueAPIResponse{Key: project + "-1"}, nil
+		}
+	}
+
+	existing, err := c.CreateOnce("FLYTE", "INC1234567", create("FLYTE"))
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	existing, err = c.CreateOnce("OPS", "INC1234567", create("OPS"))
+	require.NoError(t, err)
+	assert.Nil(t, existing, "the issue of the other project is not a duplicate")
+	existing, err = c.CreateOnce("ops", "INC1234567", create("OPS"))
+	require.NoError(t, err)
+	assert.Equal(t, &CreateIssueAPIResponse{Key: "OPS-1"}, existing)
+
+	assert.Equal(t, []string{
+		`project = "FLYTE" AND labels = "flyte-dedupe-INC1234567" ORDER BY created ASC`,
+		`project = "OPS" AND labels = "flyte-dedupe-INC1234567" ORDER BY created ASC`,
+	}, *queries)
+}
+
+func TestCreateOnceReturnsExistingIssue(t *testing.T) {
+	c, _ := searchServer(t, http.StatusOK, `[{"id": "10001", "key": "FLYTE-1", "self": "https://jira/rest/api/2/issue/10001"}]`)
+
+	existing, err := c.CreateOnce("FLYTE", "INC1234567", func(label string) (CreateIssueAPIResponse, error) {
+		t.Error("the issue should not be created")
+		return CreateIssueAPIResponse{}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &CreateIssueAPIResponse{ID: "10001", Key: "FLYTE-1", Self: "https://jira/rest/api/2/issue/10001"}, existing)
+}
+
+func TestCreateOnceSerialisesConcurrentCalls(t *testing.T) {
+	c, _ := searchServer(t, http.StatusOK, `[]`)
+	var creations int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.CreateOnce("FLYTE", "INC1234567", func(label string) (CreateIssueAPIResponse, error) {
+				atomic.AddInt32(&creations, 1)
+				return CreateIssueAPIResponse{Key: "FLYTE-1"}, nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), creations)
+	assert.Empty(t, c.dedupe.locks)
+}
+
+func TestCreateOnceWithInvalidKey(t *testing.T) {
+	c, queries := searchServer(t, http.StatusOK, `[]`)
+
+	_, err := c.CreateOnce("FLYTE", "INC 1234567", func(label string) (CreateIssueAPIResponse, error) {
+		t.Error("the issue should not be created")
+		return CreateIssueAPIResponse{}, nil
+	})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.EqualError(t, err, "issue is not valid : dedupeKey: must not contain spaces, it is stored as a label")
+	assert.Empty(t, *queries)
+}
+
+func TestCreateOnceWhenSearchFails(t *testing.T) {
+	c, _ := searchServer(t, http.StatusBadRequest, `[]`)
+
+	_, err := c.CreateOnce("FLYTE", "INC1234567", func(label string) (CreateIssueAPIResponse, error) {
+		t.Error("the issue should not be created when duplicates cannot be ruled out")
+		return CreateIssueAPIResponse{}, nil
+	})
+
+	assert.EqualError(t, err, `dedupeKey=INC1234567 : query='project = "FLYTE" AND labels = "flyte-dedupe-INC1234567" ORDER BY created ASC' : statusCode=400`)
+}
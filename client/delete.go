@@ -0,0 +1,188 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNotAllowed is returned when DeletionConfig forbids deleting or
+	// archiving an issue.
+	ErrNotAllowed = errors.New("not allowed")
+	// ErrNotSupported is returned when the Jira instance does not offer an
+	// operation, e.g. archiving issues outside of Data Center.
+	ErrNotSupported = errors.New("not supported by this jira instance")
+)
+
+type (
+	// DeletionConfig guards DeleteIssue and ArchiveIssue, so that a flow
+	// going wrong cannot remove real work. Nothing can be deleted or
+	// archived with the zero value.
+	DeletionConfig struct {
+		// Projects are the keys of the projects whose issues may be
+		// deleted or archived.
+		Projects []string
+		// OwnIssuesOnly restricts deleting and archiving to the issues
+		// created by the user the pack authenticates as.
+		OwnIssuesOnly bool
+	}
+
+	// jiraUser identifies a user on Jira Server/Data Center (name and key)
+	// or on Jira Cloud (accountId).
+	jiraUser struct {
+		Name      string `json:"name"`
+		Key       string `json:"key"`
+		AccountID string `json:"accountId"`
+	}
+)
+
+func (u jiraUser) String() string {
+	switch {
+	case u.AccountID != "":
+		return u.AccountID
+	case u.Name != "":
+		return u.Name
+	}
+	return "an unknown user"
+}
+
+func (u jiraUser) is(other jiraUser) bool {
+	switch {
+	case u.AccountID != "" || other.AccountID != "":
+		return u.AccountID == other.AccountID
+	case u.Key != "" && other.Key != "":
+		return u.Key == other.Key
+	}
+	return u.Name != "" && u.Name == other.Name
+}
+
+// DeleteIssue deletes the issue issueKey, and its sub-tasks if deleteSubtasks
+// is set, Jira refusing to delete an issue with sub-tasks otherwise.
+func (c *Client) DeleteIssue(issueKey string, deleteSubtasks bool) error {
+	removed, err := c.checkRemovable(issueKey, deleteSubtasks)
+	if err != nil {
+		return err
+	}
+
+	request, err := c.newRequest(http.MethodDelete, fmt.Sprintf("/rest/api/2/issue/%s?deleteSubtasks=%t", issueKey, deleteSubtasks), nil)
+	if err != nil {
+		return err
+	}
+	if err := c.sendRequestWithoutResp(request); err != nil {
+		return fmt.Errorf("issueId=%s : %w", issueKey, err)
+	}
+	c.dedupe.forget(removed...)
+	return nil
+}
+
+// ArchiveIssue archives the issue issueKey, which Jira Data Center 8.1 and
+// later supports.
+func (c *Client) ArchiveIssue(issueKey string) error {
+	if _, err := c.checkRemovable(issueKey, false); err != nil {
+		return err
+	}
+
+	request, err := c.newRequest(http.MethodPut, fmt.Sprintf("/rest/api/2/issue/%s/archive", issueKey), nil)
+	if err != nil {
+		return err
+	}
+	if err := c.sendRequestWithoutResp(request); err != nil {
+		var jiraErr *JiraError
+		if errors.As(err, &jiraErr) && (jiraErr.StatusCode == http.StatusNotFound || jiraErr.StatusCode == http.StatusMethodNotAllowed) {
+			// the issue was just found, it is the archive API that is missing
+			return fmt.Errorf("issueId=%s : archiving issues is %w : %v", issueKey, ErrNotSupported, err)
+		}
+		return fmt.Errorf("issueId=%s : %w", issueKey, err)
+	}
+	c.dedupe.forget(issueKey)
+	return nil
+}
+
+// checkRemovable returns ErrNotAllowed unless the DeletionConfig lets the
+// issue issueKey, and its sub-tasks if withSubtasks is set, be removed. It
+// returns the keys of the issues that would be removed.
+func (c *Client) checkRemovable(issueKey string, withSubtasks bool) ([]string, error) {
+	issue, err := c.getRawIssue(issueKey, "project", "creator", "subtasks")
+	if err != nil {
+		return nil, err
+	}
+	project := valueKey(issue.Fields["project"], "key")
+	if !c.deletionAllowedIn(project) {
+		return nil, fmt.Errorf("issueId=%s : %w: project %s is not one of the projects issues may be deleted from", issueKey, ErrNotAllowed, project)
+	}
+	keys := []string{issue.Key}
+	if withSubtasks {
+		subtasks, _ := issue.Fields["subtasks"].([]interface{})
+		for _, subtask := range subtasks {
+			keys = append(keys, valueKey(subtask, "key"))
+		}
+	}
+	if !c.config.Deletion.OwnIssuesOnly {
+		return keys, nil
+	}
+
+	me, err := c.myself()
+	if err != nil {
+		return nil, fmt.Errorf("issueId=%s : %w", issueKey, err)
+	}
+	issues := []rawIssue{issue}
+	for _, key := range keys[1:] {
+		// the sub-tasks listed in the parent do not tell their creator
+		subtask, err := c.getRawIssue(key, "creator")
+		if err != nil {
+			return nil, fmt.Errorf("issueId=%s : %w", issueKey, err)
+		}
+		issues = append(issues, subtask)
+	}
+	for _, issue := range issues {
+		var creator jiraUser
+		if m, ok := issue.Fields["creator"].(map[string]interface{}); ok {
+			creator = jiraUser{Name: valueKey(m, "name"), Key: valueKey(m, "key"), AccountID: valueKey(m, "accountId")}
+		}
+		if !creator.is(me) {
+			return nil, fmt.Errorf("issueId=%s : %w: %s was created by %s, not by %s", issueKey, ErrNotAllowed, issue.Key, creator, me)
+		}
+	}
+	return keys, nil
+}
+
+// deletionAllowedIn tells whether project is one of the projects of the
+// DeletionConfig, ignoring case and surrounding spaces.
+func (c *Client) deletionAllowedIn(project string) bool {
+	project = strings.ToUpper(strings.TrimSpace(project))
+	for _, p := range c.config.Deletion.Projects {
+		if strings.ToUpper(strings.TrimSpace(p)) == project && project != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// myself returns the user the pack authenticates as.
+func (c *Client) myself() (jiraUser, error) {
+	var user jiraUser
+	request, err := c.newRequest(http.MethodGet, "/rest/api/2/myself", nil)
+	if err != nil {
+		return user, err
+	}
+	err = c.sendRequest(request, &user)
+	return user, err
+}
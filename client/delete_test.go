@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// deleteServer serves FLYTE-1, created by the pack user, and its sub-task
+// FLYTE-2, created by someone else, and records the requests removing issues.
+func deleteServer(t *testing.T, deletion DeletionConfig, archiveStatus int) (*Client, *[]string) {
+	var removals []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodGet:
+			removals = append(removals, r.Method+" "+r.URL.RequestURI())
+			if r.Method == http.MethodPut {
+				w.WriteHeader(archiveStatus)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/rest/api/2/myself":
+			w.Write([]byte(`{"name": "flyte", "key": "flyte"}`))
+		case r.URL.Path == "/rest/api/2/issue/FLYTE-1":
+			w.Write([]byte(`{"key": "FLYTE-1", "fields": {"project": {"key": "FLYTE"}, "creator": {"name": "flyte", "key": "flyte"}, "subtasks": [{"key": "FLYTE-2"}]}}`))
+		case r.URL.Path == "/rest/api/2/issue/FLYTE-2":
+			w.Write([]byte(`{"key": "FLYTE-2", "fields": {"project": {"key": "FLYTE"}, "creator": {"name": "jdoe", "key": "jdoe"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL, Deletion: deletion}), &removals
+}
+
+func TestDeleteIssue(t *testing.T) {
+	c, removals := deleteServer(t, DeletionConfig{Projects: []string{"OPS", "FLYTE"}, OwnIssuesOnly: true}, http.StatusNoContent)
+
+	err := c.DeleteIssue("FLYTE-1", false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DELETE /rest/api/2/issue/FLYTE-1?deleteSubtasks=false"}, *removals)
+}
+
+func TestDeleteIssueIgnoresCaseOfAllowedProjects(t *testing.T) {
+	c, removals := deleteServer(t, DeletionConfig{Projects: []string{" flyte "}}, http.StatusNoContent)
+
+	err := c.DeleteIssue("FLYTE-1", false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DELETE /rest/api/2/issue/FLYTE-1?deleteSubtasks=false"}, *removals)
+}
+
+func TestDeleteIssueOutsideOfAllowedProjects(t *testing.T) {
+	for name, deletion := range map[string]DeletionConfig{
+		"no project":    {},
+		"other project": {Projects: []string{"OPS"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, removals := deleteServer(t, deletion, http.StatusNoContent)
+
+			err := c.DeleteIssue("FLYTE-1", false)
+
+			assert.True(t, errors.Is(err, ErrNotAllowed))
+			assert.EqualError(t, err, "issueId=FLYTE-1 : not allowed: project FLYTE is not one of the projects issues may be deleted from")
+			assert.Empty(t, *removals)
+		})
+	}
+}
+
+func TestDeleteIssueWithSubtasksCreatedBySomeoneElse(t *testing.T) {
+	c, removals := deleteServer(t, DeletionConfig{Projects: []string{"FLYTE"}, OwnIssuesOnly: true}, http.StatusNoContent)
+
+	err := c.DeleteIssue("FLYTE-1", true)
+
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.EqualError(t, err, "issueId=FLYTE-1 : not allowed: FLYTE-2 was created by jdoe, not by flyte")
+	assert.Empty(t, *removals)
+}
+
+func TestDeleteIssueWithSubtasks(t *testing.T) {
+	c, removals := deleteServer(t, DeletionConfig{Projects: []string{"FLYTE"}}, http.StatusNoContent)
+
+	err := c.DeleteIssue("FLYTE-1", true)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DELETE /rest/api/2/issue/FLYTE-1?deleteSubtasks=true"}, *removals)
+}
+
+func TestDeleteIssueForgetsRecentCreation(t *testing.T) {
+	c, _ := deleteServer(t, DeletionConfig{Projects: []string{"FLYTE"}}, http.StatusNoContent)
+	c.dedupe.remember(dedupeLabelPrefix+"INC1", CreateIssueAPIResponse{Key: "FLYTE-2"})
+
+	require.NoError(t, c.DeleteIssue("FLYTE-1", true))
+
+	_, ok := c.dedupe.recentlyCreated(dedupeLabelPrefix + "INC1")
+	assert.False(t, ok)
+}
+
+func TestArchiveIssue(t *testing.T) {
+	c, removals := deleteServer(t, DeletionConfig{Projects: []string{"FLYTE"}, OwnIssuesOnly: true}, http.StatusNoContent)
+
+	err := c.ArchiveIssue("FLYTE-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PUT /rest/api/2/issue/FLYTE-1/archive"}, *removals)
+}
+
+func TestArchiveIssueNotSupported(t *testing.T) {
+	c, _ := deleteServer(t, DeletionConfig{Projects: []string{"FLYTE"}}, http.StatusNotFound)
+
+	err := c.ArchiveIssue("FLYTE-1")
+
+	assert.True(t, errors.Is(err, ErrNotSupported))
+}
+
+func TestJiraUserIs(t *testing.T) {
+	assert.True(t, jiraUser{AccountID: "5b10a"}.is(jiraUser{Name: "flyte", AccountID: "5b10a"}))
+	assert.False(t, jiraUser{Name: "flyte", AccountID: "5b10a"}.is(jiraUser{Name: "flyte"}))
+	assert.True(t, jiraUser{Name: "flyte", Key: "JIRAUSER1"}.is(jiraUser{Name: "renamed", Key: "JIRAUSER1"}))
+	assert.True(t, jiraUser{Name: "flyte"}.is(jiraUser{Name: "flyte", Key: "JIRAUSER1"}))
+	assert.False(t, jiraUser{}.is(jiraUser{}))
+}
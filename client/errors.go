@@ -0,0 +1,100 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// JiraError is returned when Jira answers a request with a status outside of
+// the 2xx range. It carries the error collection from the response body,
+// {"errorMessages": [...], "errors": {"field": "message"}}, when there is one.
+type JiraError struct {
+	StatusCode int
+	// Messages are the general error messages reported by Jira.
+	Messages []string
+	// FieldErrors maps the fields Jira rejected to the reason, e.g.
+	// "summary": "You must specify a summary of the issue."
+	FieldErrors map[string]string
+	// RequestID identifies the request in the Jira logs, when Jira sent one.
+	RequestID string
+	// body is the response body, for the few APIs whose errors do not
+	// fit the error collection.
+	body []byte
+}
+
+type errorCollection struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// requestIDHeaders are the headers Jira Server/Data Center and Jira Cloud
+// identify a request with, in order of preference.
+var requestIDHeaders = []string{"X-AREQUESTID", "X-Request-Id", "Atl-Traceid"}
+
+func newJiraError(response *http.Response) *JiraError {
+	jiraErr := &JiraError{StatusCode: response.StatusCode}
+	for _, header := range requestIDHeaders {
+		if id := response.Header.Get(header); id != "" {
+			jiraErr.RequestID = id
+			break
+		}
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return jiraErr
+	}
+	jiraErr.body = body
+	var errors errorCollection
+	if json.Unmarshal(body, &errors) == nil {
+		if len(errors.ErrorMessages) > 0 {
+			jiraErr.Messages = errors.ErrorMessages
+		}
+		if len(errors.Errors) > 0 {
+			jiraErr.FieldErrors = errors.Errors
+		}
+	}
+	return jiraErr
+}
+
+func (e *JiraError) Error() string {
+	var details []string
+	details = append(details, e.Messages...)
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		details = append(details, fmt.Sprintf("%s: %s", field, e.FieldErrors[field]))
+	}
+
+	msg := fmt.Sprintf("statusCode=%d", e.StatusCode)
+	if len(details) > 0 {
+		msg += " : " + strings.Join(details, "; ")
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (requestId=%s)", e.RequestID)
+	}
+	return msg
+}
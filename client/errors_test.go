@@ -0,0 +1,92 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateIssueReportsFieldErrors(t *testing.T) {
+	body := `{"errorMessages":[],"errors":{"summary":"You must specify a summary of the issue.","priority":"Priority name 'Urgent' is not valid"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-AREQUESTID", "1195x3047x1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL})
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Priority: "Urgent"})
+
+	assert.EqualError(t, err, "issueSummary='' : statusCode=400 : priority: Priority name 'Urgent' is not valid; summary: You must specify a summary of the issue. (requestId=1195x3047x1)")
+	var jiraErr *JiraError
+	require.True(t, errors.As(err, &jiraErr))
+	assert.Equal(t, &JiraError{
+		StatusCode: http.StatusBadRequest,
+		FieldErrors: map[string]string{
+			"summary":  "You must specify a summary of the issue.",
+			"priority": "Priority name 'Urgent' is not valid",
+		},
+		RequestID: "1195x3047x1",
+		body:      []byte(body),
+	}, jiraErr)
+}
+
+func TestDeleteLinkReportsErrorMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errorMessages":["No issue link with id '42' exists."],"errors":{}}`))
+	}))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL})
+
+	err := c.DeleteLink("42")
+
+	assert.EqualError(t, err, "linkId=42 : statusCode=404 : No issue link with id '42' exists.")
+}
+
+func TestJiraErrorWithoutErrorCollection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`<html><body>Bad Gateway</body></html>`))
+	}))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL})
+
+	err := c.AssignIssue("TEST-1", "jdoe")
+
+	assert.EqualError(t, err, "issueId=TEST-1 user=jdoe : statusCode=502")
+}
+
+func TestRetriedJiraError(t *testing.T) {
+	server, _ := failingServer(t, 3, http.StatusServiceUnavailable, http.Header{"X-Request-Id": {"abc"}})
+	c := newTestClient(t, Config{Host: server.URL, Retry: testRetryConfig})
+
+	_, err := c.GetIssueInfo("TEST-1")
+
+	var jiraErr *JiraError
+	require.True(t, errors.As(err, &jiraErr))
+	assert.Equal(t, "abc", jiraErr.RequestID)
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 2, retryErr.Retries)
+}
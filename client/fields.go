@@ -0,0 +1,261 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fieldCacheTTL is how long field metadata is used before it is
+	// fetched again.
+	fieldCacheTTL = time.Hour
+	// fieldCacheMinRefresh rate limits reloads triggered by unknown names,
+	// e.g. a flow repeatedly referring to a field that does not exist.
+	fieldCacheMinRefresh = time.Minute
+
+	jiraDateFormat     = "2006-01-02"
+	jiraDateTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+	// epicLinkField is the name of the custom field linking an issue to
+	// its epic in Jira Software.
+	epicLinkField = "Epic Link"
+)
+
+// ErrInvalidField is wrapped by the errors returned for custom field values
+// that cannot be resolved or converted before they are sent to Jira.
+var ErrInvalidField = errors.New("invalid custom field")
+
+type (
+	// Field describes a system or custom field as listed by /rest/api/2/field.
+	Field struct {
+		ID     string      `json:"id"`
+		Name   string      `json:"name"`
+		Custom bool        `json:"custom"`
+		Schema FieldSchema `json:"schema"`
+	}
+
+	// FieldSchema is the type of a field. Type is e.g. "string", "number",
+	// "date", "option", "user" or "array", in which case Items holds the
+	// type of the elements.
+	FieldSchema struct {
+		Type   string `json:"type"`
+		Items  string `json:"items,omitempty"`
+		Custom string `json:"custom,omitempty"`
+	}
+
+	// fieldCache holds the field metadata of a Jira instance, indexed by id
+	// and by lower-cased name.
+	fieldCache struct {
+		mu     sync.Mutex
+		byID   map[string]Field
+		byName map[string][]Field
+		loaded time.Time
+	}
+)
+
+// Fields returns the system and custom fields of the Jira instance.
+func (c *Client) Fields() ([]Field, error) {
+	var fields []Field
+	request, err := c.newRequest(http.MethodGet, "/rest/api/2/field", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.sendRequest(request, &fields); err != nil {
+		return nil, fmt.Errorf("cannot get fields : %w", err)
+	}
+	return fields, nil
+}
+
+// resolveCustomFields turns values keyed by field name (or id) into values
+// keyed by field id, converted to the shape Jira expects for the field type.
+func (c *Client) resolveCustomFields(values map[string]interface{}) (map[string]interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := map[string]interface{}{}
+	refreshed := false
+	for _, name := range names {
+		matches, err := c.lookupField(name, false)
+		if err == nil && len(matches) == 0 && !refreshed {
+			// the field may have been created since the cache was filled
+			refreshed = true
+			matches, err = c.lookupField(name, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%w %q: no such field", ErrInvalidField, name)
+		case 1:
+		default:
+			ids := make([]string, len(matches))
+			for i, field := range matches {
+				ids[i] = field.ID
+			}
+			return nil, fmt.Errorf("%w %q: the name is used by %s, use the field id instead", ErrInvalidField, name, strings.Join(ids, ", "))
+		}
+
+		field := matches[0]
+		value, err := c.cloudFieldValue(field, values[name])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidField, name, err)
+		}
+		if value, err = coerceFieldValue(field, value); err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidField, name, err)
+		}
+		resolved[field.ID] = value
+	}
+	return resolved, nil
+}
+
+// lookupField returns the field with the id name, or else the fields called
+// name, ignoring case.
+func (c *Client) lookupField(name string, refresh bool) ([]Field, error) {
+	c.fields.mu.Lock()
+	defer c.fields.mu.Unlock()
+
+	age := time.Since(c.fields.loaded)
+	if c.fields.byID == nil || age > fieldCacheTTL || (refresh && age > fieldCacheMinRefresh) {
+		fields, err := c.Fields()
+		if err != nil {
+			return nil, err
+		}
+		c.fields.index(fields)
+	}
+
+	if field, ok := c.fields.byID[name]; ok {
+		return []Field{field}, nil
+	}
+	return c.fields.byName[strings.ToLower(strings.TrimSpace(name))], nil
+}
+
+func (f *fieldCache) index(fields []Field) {
+	f.byID = map[string]Field{}
+	f.byName = map[string][]Field{}
+	for _, field := range fields {
+		f.byID[field.ID] = field
+		name := strings.ToLower(field.Name)
+		f.byName[name] = append(f.byName[name], field)
+	}
+	f.loaded = time.Now()
+}
+
+// coerceFieldValue converts the plain value a flow provides, e.g. "High" or
+// "5", to the representation of the field type, e.g. {"value": "High"} for a
+// select list. Values that already are JSON objects are passed on as is.
+func coerceFieldValue(field Field, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if field.Schema.Type == "array" {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		coerced := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := coerceValue(field.Schema.Items, item)
+			if err != nil {
+				return nil, err
+			}
+			coerced[i] = v
+		}
+		return coerced, nil
+	}
+	return coerceValue(field.Schema.Type, value)
+}
+
+func coerceValue(schemaType string, value interface{}) (interface{}, error) {
+	if _, ok := value.(map[string]interface{}); ok {
+		return value, nil
+	}
+
+	switch schemaType {
+	case "number":
+		switch v := value.(type) {
+		case float64, int:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected a number, got %q", v)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("expected a number, got %v", value)
+	case "string":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(value), nil
+	case "date":
+		t, err := parseTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(jiraDateFormat), nil
+	case "datetime":
+		t, err := parseTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(jiraDateTimeFormat), nil
+	case "option", "option-with-child":
+		return map[string]interface{}{"value": fmt.Sprint(value)}, nil
+	case "user", "priority", "version", "component", "issuetype", "resolution", "group":
+		return map[string]interface{}{"name": fmt.Sprint(value)}, nil
+	case "project", "issuelink":
+		return map[string]interface{}{"key": fmt.Sprint(value)}, nil
+	}
+	return value, nil
+}
+
+// parseTime accepts a date ("2006-01-02") or an RFC 3339 timestamp, as well as
+// the timestamps Jira returns.
+func parseTime(value interface{}) (time.Time, error) {
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected a date, got %v", value)
+	}
+	if t, err := time.Parse(jiraDateFormat, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(jiraDateTimeFormat, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expected a date like 2006-01-02 or 2006-01-02T15:04:05Z, got %q", s)
+}
@@ -0,0 +1,236 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testFields = []Field{
+	{ID: "summary", Name: "Summary", Schema: FieldSchema{Type: "string"}},
+	{ID: "customfield_10002", Name: "Story Points", Custom: true, Schema: FieldSchema{Type: "number"}},
+	{ID: "customfield_10003", Name: "Team", Custom: true, Schema: FieldSchema{Type: "option"}},
+	{ID: "customfield_10004", Name: "Reviewer", Custom: true, Schema: FieldSchema{Type: "user"}},
+	{ID: "customfield_10005", Name: "Go Live", Custom: true, Schema: FieldSchema{Type: "date"}},
+	{ID: "customfield_10006", Name: "Affected Regions", Custom: true, Schema: FieldSchema{Type: "array", Items: "option"}},
+	{ID: "customfield_10007", Name: "Severity", Custom: true, Schema: FieldSchema{Type: "option"}},
+	{ID: "customfield_10008", Name: "Severity", Custom: true, Schema: FieldSchema{Type: "string"}},
+	{ID: "customfield_10009", Name: "Epic Link", Custom: true, Schema: FieldSchema{Type: "any", Custom: "com.pyxis.greenhopper.jira:gh-epic-link"}},
+}
+
+// fieldServer serves testFields and records the body of the last other
+// request it gets.
+func fieldServer(t *testing.T) (*Client, *int32, *map[string]interface{}) {
+	var fieldCalls int32
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/field" {
+			atomic.AddInt32(&fieldCalls, 1)
+			json.NewEncoder(w).Encode(testFields)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(b, &body)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-1"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL}), &fieldCalls, &body
+}
+
+func TestCreateIssueWithCustomFields(t *testing.T) {
+	c, fieldCalls, body := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", CustomFields: map[string]interface{}{
+		"story points":      "3",
+		"Team":              "Platform",
+		"Reviewer":          "jdoe",
+		"Go Live":           "2026-11-01T10:00:00Z",
+		"Affected Regions":  []interface{}{"EU", "US"},
+		"customfield_10008": "high",
+	}})
+	require.NoError(t, err)
+
+	fields := (*body)["fields"].(map[string]interface{})
+	assert.Equal(t, "summary", fields["summary"])
+	assert.Equal(t, 3.0, fields["customfield_10002"])
+	assert.Equal(t, map[string]interface{}{"value": "Platform"}, fields["customfield_10003"])
+	assert.Equal(t, map[string]interface{}{"name": "jdoe"}, fields["customfield_10004"])
+	assert.Equal(t, "2026-11-01", fields["customfield_10005"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"value": "EU"}, map[string]interface{}{"value": "US"}}, fields["customfield_10006"])
+	assert.Equal(t, "high", fields["customfield_10008"])
+
+	// field metadata is cached
+	_, err = c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", CustomFields: map[string]interface{}{"Team": "Platform"}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(fieldCalls))
+}
+
+func TestCreateIssueWithStandardFields(t *testing.T) {
+	c, _, body := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{
+		Project:         "FLYTE",
+		IssueType:       "Story",
+		Summary:         "summary",
+		Priority:        " High ",
+		Assignee:        " jdoe ",
+		Labels:          []string{"incident", "sev2"},
+		Components:      []string{"api", "ui"},
+		FixVersions:     []string{"1.2"},
+		AffectsVersions: []string{"1.0", "1.1"},
+		DueDate:         "2026-11-01T10:00:00Z",
+		Environment:     "production",
+		Parent:          "FLYTE-7",
+		EpicLink:        "FLYTE-1",
+	})
+	require.NoError(t, err)
+
+	fields := (*body)["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "High"}, fields["priority"])
+	assert.Equal(t, map[string]interface{}{"name": "jdoe"}, fields["assignee"])
+	assert.Equal(t, []interface{}{"incident", "sev2"}, fields["labels"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "api"}, map[string]interface{}{"name": "ui"}}, fields["components"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "1.2"}}, fields["fixVersions"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "1.0"}, map[string]interface{}{"name": "1.1"}}, fields["versions"])
+	assert.Equal(t, "2026-11-01", fields["duedate"])
+	assert.Equal(t, "production", fields["environment"])
+	assert.Equal(t, map[string]interface{}{"key": "FLYTE-7"}, fields["parent"])
+	assert.Equal(t, "FLYTE-1", fields["customfield_10009"])
+}
+
+func TestCreateIssueOmitsUnsetStandardFields(t *testing.T) {
+	c, _, body := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary"})
+	require.NoError(t, err)
+
+	fields := (*body)["fields"].(map[string]interface{})
+	for _, field := range []string{"priority", "assignee", "labels", "components", "fixVersions", "versions", "duedate", "environment", "parent"} {
+		assert.NotContains(t, fields, field)
+	}
+}
+
+func TestCreateIssueWithInvalidDueDate(t *testing.T) {
+	c, _, _ := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", DueDate: "next friday"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Equal(t, map[string]string{"duedate": `expected a date like 2006-01-02 or 2006-01-02T15:04:05Z, got "next friday"`}, validationErr.FieldErrors)
+}
+
+func TestCreateIssueConvertsMarkdownDescription(t *testing.T) {
+	c, _, body := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", Description: "some **bold** text", Format: markup.Markdown})
+	require.NoError(t, err)
+
+	fields := (*body)["fields"].(map[string]interface{})
+	assert.Equal(t, "some *bold* text", fields["description"])
+}
+
+func TestCreateIssueWithInvalidFormat(t *testing.T) {
+	c, _, _ := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", Description: "text", Format: "html"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Contains(t, validationErr.FieldErrors, "format")
+}
+
+func TestUpdateIssueWithCustomFields(t *testing.T) {
+	c, _, body := fieldServer(t)
+
+	err := c.UpdateIssue("FLYTE-1", IssueUpdate{
+		Fields:       map[string]interface{}{"summary": "New summary"},
+		CustomFields: map[string]interface{}{"Story Points": 5},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"fields": map[string]interface{}{"summary": "New summary", "customfield_10002": 5.0},
+	}, *body)
+}
+
+func TestInvalidCustomFields(t *testing.T) {
+	c, _, _ := fieldServer(t)
+
+	tests := map[string]map[string]interface{}{
+		`issueSummary='summary' : invalid custom field "Sprint": no such field`:                                                                        {"Sprint": "1"},
+		`issueSummary='summary' : invalid custom field "Severity": the name is used by customfield_10007, customfield_10008, use the field id instead`: {"Severity": "high"},
+		`issueSummary='summary' : invalid custom field "Story Points": expected a number, got "three"`:                                                 {"Story Points": "three"},
+		`issueSummary='summary' : invalid custom field "Go Live": expected a date like 2006-01-02 or 2006-01-02T15:04:05Z, got "soon"`:                 {"Go Live": "soon"},
+	}
+	for expected, customFields := range tests {
+		_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", CustomFields: customFields})
+		assert.EqualError(t, err, expected)
+		assert.True(t, errors.Is(err, ErrInvalidField))
+	}
+}
+
+func TestUnknownFieldRefreshesStaleCache(t *testing.T) {
+	c, fieldCalls, _ := fieldServer(t)
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", CustomFields: map[string]interface{}{"Sprint": "1"}})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(fieldCalls), "fields were just loaded")
+
+	c.fields.loaded = time.Now().Add(-2 * fieldCacheMinRefresh)
+	_, err = c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Story", Summary: "summary", CustomFields: map[string]interface{}{"Sprint": "1"}})
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(fieldCalls))
+}
+
+func TestCoerceFieldValue(t *testing.T) {
+	tests := []struct {
+		schema   FieldSchema
+		value    interface{}
+		expected interface{}
+	}{
+		{FieldSchema{Type: "number"}, 2.5, 2.5},
+		{FieldSchema{Type: "string"}, 42.0, "42"},
+		{FieldSchema{Type: "datetime"}, "2026-11-01T10:00:00+02:00", "2026-11-01T10:00:00.000+0200"},
+		{FieldSchema{Type: "option"}, map[string]interface{}{"id": "10100"}, map[string]interface{}{"id": "10100"}},
+		{FieldSchema{Type: "array", Items: "string"}, "triaged", []interface{}{"triaged"}},
+		{FieldSchema{Type: "array", Items: "user"}, []interface{}{"jdoe"}, []interface{}{map[string]interface{}{"name": "jdoe"}}},
+		{FieldSchema{Type: "project"}, "FLYTE", map[string]interface{}{"key": "FLYTE"}},
+		{FieldSchema{Type: "any"}, true, true},
+	}
+	for _, test := range tests {
+		actual, err := coerceFieldValue(Field{Schema: test.schema}, test.value)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, actual, test.schema.Type)
+	}
+}
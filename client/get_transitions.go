@@ -1,7 +1,6 @@
 package client
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 )
@@ -14,19 +13,15 @@ type TransitionObj struct {
 	TransitionName string `json:"name"`
 }
 
-func GetTransitions(issueId string) (TransitionsResult, error) {
+func (c *Client) GetTransitions(issueId string) (TransitionsResult, error) {
 	result := TransitionsResult{Transitions: []TransitionObj{}}
 	path := fmt.Sprintf("/rest/api/2/issue/%s/transitions", issueId)
-	request, err := constructGetRequest(path)
+	request, err := c.newRequest(http.MethodGet, path, nil)
 	if err != nil {
 		return result, err
 	}
-	statusCode, err := SendRequest(request, &result)
-	if err != nil {
-		return result, err
-	}
-	if statusCode != http.StatusOK {
-		return result, errors.New("Issue does not exist")
+	if err := c.sendRequest(request, &result); err != nil {
+		return result, fmt.Errorf("issueId=%s : %w", issueId, err)
 	}
 	return result, nil
 }
@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// defaultSubtaskType is assumed when the create metadata of a project cannot
+// be read. It is the name of the sub-task type of classic projects.
+const defaultSubtaskType = "Sub-task"
+
+// NewSubtask prepares subtask to be created as a sub-task of the issue
+// parentKey. The project defaults to the one of the parent and the issue type
+// to the sub-task issue type of the project.
+func (c *Client) NewSubtask(parentKey string, subtask NewIssue) (NewIssue, error) {
+	subtask.Parent = parentKey
+	if subtask.Project == "" {
+		subtask.Project = projectOf(parentKey)
+	}
+	if subtask.IssueType == "" {
+		issueType, err := c.subtaskType(subtask.Project)
+		if err != nil {
+			return subtask, fmt.Errorf("parentId=%s : %w", parentKey, err)
+		}
+		subtask.IssueType = issueType
+	}
+	return subtask, nil
+}
+
+// subtaskType returns the name of the sub-task issue type of project, which
+// differs between classic ("Sub-task") and next-gen ("Subtask") projects and
+// may have been renamed.
+func (c *Client) subtaskType(project string) (string, error) {
+	issueTypes, err := c.projectIssueTypes(project)
+	if err != nil {
+		log.Printf("cannot look up the sub-task type of project %s, using %q: %v", project, defaultSubtaskType, err)
+		return defaultSubtaskType, nil
+	}
+	if issueTypes == nil {
+		// leave it to validateCreate to report the missing project
+		return defaultSubtaskType, nil
+	}
+	for _, t := range issueTypes {
+		if t.Subtask {
+			return t.Name, nil
+		}
+	}
+	return "", &ValidationError{
+		Project:     project,
+		FieldErrors: map[string]string{"issuetype": fmt.Sprintf("project %s has no sub-task issue type", project)},
+	}
+}
+
+// GetChildIssues returns the issues below issueKey in the hierarchy: the
+// sub-tasks of an issue, or the issues of an epic. Epics are matched both
+// through the parent field of next-gen projects and Jira Cloud and through
+// the Epic Link custom field of classic projects.
+func (c *Client) GetChildIssues(issueKey string, startIndex, maxResults int) (SearchResult, error) {
+	key := jqlString(strings.TrimSpace(issueKey))
+	query := fmt.Sprintf("parent = %s", key)
+	if epicLink, err := c.lookupField(epicLinkField, false); err != nil {
+		log.Printf("cannot look up the %s field, only searching by parent: %v", epicLinkField, err)
+	} else if len(epicLink) == 1 {
+		query += fmt.Sprintf(" OR cf[%s] = %s", strings.TrimPrefix(epicLink[0].ID, "customfield_"), key)
+	}
+	return c.SearchIssues(query+" ORDER BY key ASC", startIndex, maxResults)
+}
+
+// jqlString quotes s for use as a value in a JQL query.
+func jqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hierarchyServer serves the issue types of the FLYTE project, answers
+// searches with no results and records the fields of created issues and the
+// search queries.
+func hierarchyServer(t *testing.T, issueTypes string, fields []Field) (*Client, *map[string]interface{}, *string) {
+	var created map[string]interface{}
+	var jql string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rest/api/2/field":
+			json.NewEncoder(w).Encode(fields)
+		case r.URL.Path == "/rest/api/2/issue/createmeta/FLYTE/issuetypes" && issueTypes != "":
+			w.Write([]byte(issueTypes))
+		case r.URL.Path == "/rest/api/2/search":
+			var search SearchRequestType
+			json.NewDecoder(r.Body).Decode(&search)
+			jql = search.Query
+			w.Write([]byte(`{"total": 1, "issues": [{"key": "FLYTE-2"}]}`))
+		case r.Method == http.MethodPost:
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(b, &body)
+			created = body.Fields
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-2"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL}), &created, &jql
+}
+
+func createSubtask(c *Client, parentKey string, subtask NewIssue) (domain.Issue, error) {
+	subtask, err := c.NewSubtask(parentKey, subtask)
+	if err != nil {
+		return domain.Issue{}, err
+	}
+	return c.CreateIssue(subtask)
+}
+
+func TestCreateSubtaskUsesSubtaskTypeOfProject(t *testing.T) {
+	c, created, _ := hierarchyServer(t, `{"values": [{"id": "1", "name": "Task"}, {"id": "5", "name": "Subtask", "subtask": true}], "isLast": true}`, nil)
+
+	issue, err := createSubtask(c, "FLYTE-1", NewIssue{Summary: "summary"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "FLYTE-2", issue.Key)
+	assert.Equal(t, map[string]interface{}{"key": "FLYTE"}, (*created)["project"])
+	assert.Equal(t, map[string]interface{}{"name": "Subtask"}, (*created)["issuetype"])
+	assert.Equal(t, map[string]interface{}{"key": "FLYTE-1"}, (*created)["parent"])
+}
+
+func TestCreateSubtaskWithExplicitIssueType(t *testing.T) {
+	c, created, _ := hierarchyServer(t, "", nil)
+
+	_, err := createSubtask(c, "FLYTE-1", NewIssue{Project: "FLYTE", IssueType: "Technical task", Summary: "summary"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "Technical task"}, (*created)["issuetype"])
+}
+
+func TestCreateSubtaskWithoutCreateMeta(t *testing.T) {
+	c, created, _ := hierarchyServer(t, "", nil)
+
+	_, err := createSubtask(c, "FLYTE-1", NewIssue{Summary: "summary"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": defaultSubtaskType}, (*created)["issuetype"])
+}
+
+func TestCreateSubtaskInProjectWithoutSubtasks(t *testing.T) {
+	c, created, _ := hierarchyServer(t, `{"values": [{"id": "1", "name": "Task"}], "isLast": true}`, nil)
+
+	_, err := createSubtask(c, "FLYTE-1", NewIssue{Summary: "summary"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.EqualError(t, err, "parentId=FLYTE-1 : issue is not valid for project FLYTE : issuetype: project FLYTE has no sub-task issue type")
+	assert.Nil(t, *created)
+}
+
+func TestGetChildIssues(t *testing.T) {
+	c, _, jql := hierarchyServer(t, "", testFields)
+
+	result, err := c.GetChildIssues("FLYTE-1", 0, 50)
+
+	require.NoError(t, err)
+	assert.Equal(t, `parent = "FLYTE-1" OR cf[10009] = "FLYTE-1" ORDER BY key ASC`, *jql)
+	assert.Equal(t, 1, result.TotalResults)
+	assert.Equal(t, "FLYTE-2", result.Issues[0].Key)
+}
+
+func TestGetChildIssuesWithoutEpicLink(t *testing.T) {
+	c, _, jql := hierarchyServer(t, "", []Field{{ID: "summary", Name: "Summary"}})
+
+	_, err := c.GetChildIssues(`FLYTE-1" OR project = SECRET`, 0, 50)
+
+	require.NoError(t, err)
+	assert.Equal(t, `parent = "FLYTE-1\" OR project = SECRET" ORDER BY key ASC`, *jql)
+}
@@ -17,70 +17,60 @@ limitations under the License.
 package client
 
 import (
-	"crypto/tls"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io/ioutil"
 	"net/http"
 )
 
-var (
-	SendRequest            = sendRequest
-	SendCustomRequest      = sendCustomRequest
-	SendRequestWithoutResp = sendRequestWithoutResp
-)
+// send sends request and turns a response outside of the 2xx range into a
+// *JiraError. When the request was retried in vain the *JiraError is wrapped
+// in the *RetryError, so callers see both why Jira refused the request and
+// how often it was tried.
+func (c *Client) send(request *http.Request) (*http.Response, error) {
+	response, err := c.do(request)
+	if response == nil {
+		return nil, err
+	}
+	if response.StatusCode >= 200 && response.StatusCode <= 299 {
+		return response, nil
+	}
 
-func sendRequest(request *http.Request, responseBody interface{}) (responseCode int, err error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	defer response.Body.Close()
+	jiraErr := newJiraError(response)
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		return nil, &RetryError{Retries: retryErr.Retries, Reason: retryErr.Reason, Err: jiraErr}
 	}
+	return nil, jiraErr
+}
 
-	client := &http.Client{Transport: tr}
-	response, err := client.Do(request)
+func (c *Client) sendRequest(request *http.Request, responseBody interface{}) error {
+	response, err := c.send(request)
 	if err != nil {
-		return -1, err
+		return err
 	}
-
 	defer response.Body.Close()
 
-	return response.StatusCode, json.NewDecoder(response.Body).Decode(&responseBody)
+	return json.NewDecoder(response.Body).Decode(responseBody)
 }
 
-func sendRequestWithoutResp(request *http.Request) (responseCode int, err error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-
-	client := &http.Client{Transport: tr}
-	resp, err := client.Do(request)
+func (c *Client) sendRequestWithoutResp(request *http.Request) error {
+	response, err := c.send(request)
 	if err != nil {
-		return -1, err
+		return err
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode, nil
+	return response.Body.Close()
 }
 
 // sendCustomRequest takes http.Request as an argument and return raw []byte of http.Response body.
 // It can be used in cases where we need to parse the response in some custom way in command handlers
-func sendCustomRequest(request *http.Request) ([]byte, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-
-	client := &http.Client{Transport: tr}
-	var res *http.Response
-	res, err := client.Do(request)
+func (c *Client) sendCustomRequest(request *http.Request) ([]byte, error) {
+	res, err := c.send(request)
 	if err != nil {
 		return nil, err
 	}
-
 	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-
-	return body, nil
+	return ioutil.ReadAll(res.Body)
 }
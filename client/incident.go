@@ -0,0 +1,165 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Where the ServiceNow incident of an issue created by CreateCustomIssue is
+// recorded.
+const (
+	// IncidentLabel adds the incident number as a label.
+	IncidentLabel = "label"
+	// IncidentField sets a custom field to the incident number.
+	IncidentField = "field"
+	// IncidentRemoteLink links the issue to the incident in ServiceNow.
+	IncidentRemoteLink = "remotelink"
+)
+
+// incidentPlaceholder is replaced by the incident number in
+// IncidentConfig.URLTemplate.
+const incidentPlaceholder = "{incident}"
+
+type (
+	// IncidentConfig sets where the ServiceNow incident of new issues is
+	// recorded, so that the issues of an incident can be found.
+	IncidentConfig struct {
+		// Target is IncidentLabel (the default), IncidentField or
+		// IncidentRemoteLink.
+		Target string
+		// Field is the name or id of the custom field holding the incident
+		// number, for IncidentField.
+		Field string
+		// URLTemplate is the URL of an incident, with {incident} standing
+		// for its number, for IncidentRemoteLink, e.g.
+		// https://example.service-now.com/incident.do?sysparm_query=number={incident}
+		URLTemplate string
+	}
+
+	remoteLink struct {
+		GlobalID string           `json:"globalId"`
+		Object   remoteLinkObject `json:"object"`
+	}
+
+	remoteLinkObject struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	}
+)
+
+func (i IncidentConfig) validate() error {
+	switch i.Target {
+	case "", IncidentLabel:
+	case IncidentField:
+		if i.Field == "" {
+			return errors.New("the incident field is required to record incidents in a field")
+		}
+	case IncidentRemoteLink:
+		if !strings.Contains(i.URLTemplate, incidentPlaceholder) {
+			return fmt.Errorf("the incident URL template must contain %s to record incidents as remote links", incidentPlaceholder)
+		}
+	default:
+		return fmt.Errorf("unknown incident target %q, use %s, %s or %s", i.Target, IncidentLabel, IncidentField, IncidentRemoteLink)
+	}
+	return nil
+}
+
+// incidentFields returns the labels and custom fields recording incident on a
+// new issue. Remote links cannot be searched, so issues linked to their
+// incident get its deduplication label whatever their dedupeKey, for
+// FindIssuesByIncident to find them.
+func (c *Client) incidentFields(incident string) (labels []string, custom map[string]interface{}, err error) {
+	if incident == "" {
+		return nil, nil, nil
+	}
+	switch c.config.Incident.Target {
+	case "", IncidentLabel:
+		return []string{incident}, nil, nil
+	case IncidentField:
+		custom, err := c.resolveCustomFields(map[string]interface{}{c.config.Incident.Field: incident})
+		return nil, custom, err
+	case IncidentRemoteLink:
+		return []string{dedupeLabelPrefix + incident}, nil, nil
+	}
+	return nil, nil, nil
+}
+
+// LinkIncident links the issue issueKey to the ServiceNow incident when
+// incidents are recorded as remote links, and does nothing otherwise. Linking
+// the same incident again updates the existing link.
+func (c *Client) LinkIncident(issueKey, incident string) error {
+	if c.config.Incident.Target != IncidentRemoteLink || incident == "" {
+		return nil
+	}
+
+	link := remoteLink{
+		GlobalID: "servicenow-incident=" + incident,
+		Object: remoteLinkObject{
+			URL:   strings.Replace(c.config.Incident.URLTemplate, incidentPlaceholder, url.QueryEscape(incident), -1),
+			Title: incident,
+		},
+	}
+	b, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/rest/api/2/issue/%s/remotelink", issueKey)
+	request, err := c.newRequest(http.MethodPost, path, b)
+	if err != nil {
+		return err
+	}
+	if err := c.sendRequestWithoutResp(markIdempotent(request)); err != nil {
+		return fmt.Errorf("issueId=%s incident=%s : %w", issueKey, incident, err)
+	}
+	return nil
+}
+
+// FindIssuesByIncident returns the issues recorded for the ServiceNow
+// incident. Besides the configured target, issues are found through the
+// deduplication label of the incident, which issues linked to it by remote
+// link always get, see incidentFields.
+func (c *Client) FindIssuesByIncident(incident string, startIndex, maxResults int) (SearchResult, error) {
+	dedupe := fmt.Sprintf("labels = %s", jqlString(dedupeLabelPrefix+incident))
+	var query string
+	switch c.config.Incident.Target {
+	case "", IncidentLabel:
+		query = fmt.Sprintf("labels in (%s, %s)", jqlString(incident), jqlString(dedupeLabelPrefix+incident))
+	case IncidentField:
+		matches, err := c.lookupField(c.config.Incident.Field, false)
+		if err != nil {
+			return SearchResult{}, fmt.Errorf("incident=%s : %w", incident, err)
+		}
+		if len(matches) != 1 {
+			return SearchResult{}, fmt.Errorf("incident=%s : %w %q: the incident field must match exactly one field", incident, ErrInvalidField, c.config.Incident.Field)
+		}
+		operator := "="
+		if matches[0].Schema.Type == "string" {
+			// text fields only support contains
+			operator = "~"
+		}
+		query = fmt.Sprintf("cf[%s] %s %s OR %s", strings.TrimPrefix(matches[0].ID, "customfield_"), operator, jqlString(incident), dedupe)
+	default:
+		query = dedupe
+	}
+	return c.SearchIssues(query+" ORDER BY created ASC", startIndex, maxResults)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// incidentServer serves testFields, answers searches with no results and
+// records the body of the last create, remote link or search request.
+func incidentServer(t *testing.T, incident IncidentConfig) (*Client, *map[string]interface{}, *string) {
+	var body map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rest/api/2/field":
+			json.NewEncoder(w).Encode(testFields)
+			return
+		case r.Method != http.MethodPost:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path = r.URL.Path
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"key": "FLYTE-1", "issues": []}`))
+	}))
+	t.Cleanup(server.Close)
+	return newTestClient(t, Config{Host: server.URL, Incident: incident}), &body, &path
+}
+
+func TestIncidentConfigIsValidated(t *testing.T) {
+	for _, incident := range []IncidentConfig{
+		{Target: "comment"},
+		{Target: IncidentField},
+		{Target: IncidentRemoteLink, URLTemplate: "https://example.service-now.com/incident.do"},
+	} {
+		_, err := New(Config{Host: "https://jira.example.com", Incident: incident})
+		assert.Error(t, err, "%+v", incident)
+	}
+}
+
+func TestCreateCustomIssueRecordsIncidentAsLabel(t *testing.T) {
+	c, body, _ := incidentServer(t, IncidentConfig{})
+
+	_, err := c.CreateCustomIssue("FLYTE", "Story", "summary", "description", "", "INC1234567", []string{"ops"})
+
+	require.NoError(t, err)
+	fields := (*body)["fields"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"ops", "INC1234567"}, fields["labels"])
+}
+
+func TestCreateCustomIssueRecordsIncidentInField(t *testing.T) {
+	c, body, _ := incidentServer(t, IncidentConfig{Target: IncidentField, Field: "customfield_10008"})
+
+	_, err := c.CreateCustomIssue("FLYTE", "Story", "summary", "description", "", "INC1234567", nil)
+
+	require.NoError(t, err)
+	fields := (*body)["fields"].(map[string]interface{})
+	assert.Equal(t, "INC1234567", fields["customfield_10008"])
+	assert.Nil(t, fields["labels"])
+}
+
+func TestCreateCustomIssueLinkedToIncidentGetsItsDedupeLabel(t *testing.T) {
+	c, body, _ := incidentServer(t, IncidentConfig{Target: IncidentRemoteLink, URLTemplate: "https://example.service-now.com/{incident}"})
+
+	tests := []struct {
+		labels   []string
+		expected []interface{}
+	}{
+		{[]string{"flyte-dedupe-PM-42"}, []interface{}{"flyte-dedupe-PM-42", "flyte-dedupe-INC1234567"}},
+		{[]string{"flyte-dedupe-INC1234567"}, []interface{}{"flyte-dedupe-INC1234567"}},
+	}
+	for _, test := range tests {
+		_, err := c.CreateCustomIssue("FLYTE", "Story", "summary", "description", "", "INC1234567", test.labels)
+
+		require.NoError(t, err)
+		fields := (*body)["fields"].(map[string]interface{})
+		assert.Equal(t, test.expected, fields["labels"])
+	}
+}
+
+func TestLinkIncident(t *testing.T) {
+	c, body, path := incidentServer(t, IncidentConfig{
+		Target:      IncidentRemoteLink,
+		URLTemplate: "https://example.service-now.com/incident.do?sysparm_query=number={incident}",
+	})
+
+	require.NoError(t, c.LinkIncident("FLYTE-1", "INC1234567"))
+
+	assert.Equal(t, "/rest/api/2/issue/FLYTE-1/remotelink", *path)
+	assert.Equal(t, map[string]interface{}{
+		"globalId": "servicenow-incident=INC1234567",
+		"object": map[string]interface{}{
+			"url":   "https://example.service-now.com/incident.do?sysparm_query=number=INC1234567",
+			"title": "INC1234567",
+		},
+	}, *body)
+}
+
+func TestLinkIncidentOnlyForRemoteLinks(t *testing.T) {
+	c, _, path := incidentServer(t, IncidentConfig{Target: IncidentLabel})
+
+	require.NoError(t, c.LinkIncident("FLYTE-1", "INC1234567"))
+
+	assert.Empty(t, *path)
+}
+
+func TestFindIssuesByIncident(t *testing.T) {
+	for _, test := range []struct {
+		incident IncidentConfig
+		jql      string
+	}{
+		{IncidentConfig{}, `labels in ("INC1234567", "flyte-dedupe-INC1234567") ORDER BY created ASC`},
+		{IncidentConfig{Target: IncidentField, Field: "Reviewer"}, `cf[10004] = "INC1234567" OR labels = "flyte-dedupe-INC1234567" ORDER BY created ASC`},
+		{IncidentConfig{Target: IncidentField, Field: "customfield_10008"}, `cf[10008] ~ "INC1234567" OR labels = "flyte-dedupe-INC1234567" ORDER BY created ASC`},
+		{IncidentConfig{Target: IncidentRemoteLink, URLTemplate: "https://snow/{incident}"}, `labels = "flyte-dedupe-INC1234567" ORDER BY created ASC`},
+	} {
+		c, body, _ := incidentServer(t, test.incident)
+
+		_, err := c.FindIssuesByIncident("INC1234567", 0, 50)
+
+		require.NoError(t, err)
+		assert.Equal(t, test.jql, (*body)["jql"])
+	}
+}
+
+func TestFindIssuesByIncidentWithAmbiguousField(t *testing.T) {
+	c, _, _ := incidentServer(t, IncidentConfig{Target: IncidentField, Field: "Severity"})
+
+	_, err := c.FindIssuesByIncident("INC1234567", 0, 50)
+
+	assert.EqualError(t, err, `incident=INC1234567 : invalid custom field "Severity": the incident field must match exactly one field`)
+}
@@ -22,49 +22,139 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
 )
 
-// Must be initialised before using
-var JiraConfig Config
-
 type (
+	// Config holds the settings needed to reach a single Jira instance.
 	Config struct {
-		Host     string
-		Username string
-		Password string
-	}
-
+		Host string
+		// Auth authenticates requests, they are sent anonymously when nil.
+		Auth      Authenticator
+		TLS       TLSConfig
+		Retry     RetryConfig
+		RateLimit RateLimitConfig
+		Incident  IncidentConfig
+		Deletion  DeletionConfig
+		// Deployment is DeploymentAuto (the default), DeploymentServer or
+		// DeploymentCloud.
+		Deployment string
+		// BrowseURL is the base url of the links to issues, see
+		// Client.BrowseURL.
+		BrowseURL string
+	}
+
+	// Client talks to a single Jira instance. It holds no per-request state
+	// and is safe for concurrent use by multiple command handlers.
+	Client struct {
+		config     Config
+		httpClient *http.Client
+		limiter    *limiter
+		fields     fieldCache
+		createMeta createMetaCache
+		dedupe     dedupeState
+		users      userCache
+		// deployment is the detected deployment, see DetectDeployment.
+		deployment atomic.Value
+		// baseURL is the base url of the site, read by DetectDeployment.
+		baseURL atomic.Value
+	}
+
+	// Option customises a Client created by New.
+	Option func(*Client)
+
+	// Comment is the body of a new comment. Body is wiki markup on Jira
+	// Server/Data Center and a document on Jira Cloud, see richText.
 	Comment struct {
-		Body string `json:"body"`
+		Body       interface{}        `json:"body"`
+		Visibility *domain.Visibility `json:"visibility,omitempty"`
 	}
 
 	Issue struct {
 		Fields IssueFields `json:"fields"`
 	}
 
+	// NewComment describes a comment to add to an issue.
+	NewComment struct {
+		Body string
+		// Visibility restricts the comment to the members of a project
+		// role or of a group.
+		Visibility *domain.Visibility
+		// ResolveMentions turns the @username and @email tokens of Body
+		// into user mentions.
+		ResolveMentions bool
+		// Format is the format of Body, see markup.ToWiki.
+		Format string
+	}
+
 	CustomIncIssue struct {
 		Fields CustomIncIssueFields `json:"fields"`
 	}
 
+	// NewIssue describes an issue to create. Only Project, IssueType and
+	// Summary are required, empty fields are left for Jira to default.
+	NewIssue struct {
+		Project         string
+		IssueType       string
+		Summary         string
+		Description     string
+		Priority        string
+		Reporter        string
+		Assignee        string
+		Labels          []string
+		Components      []string
+		FixVersions     []string
+		AffectsVersions []string
+		// DueDate is a date (2006-01-02) or an RFC 3339 timestamp.
+		DueDate     string
+		Environment string
+		// Parent is the key of the parent issue of a sub-task, or of the
+		// epic on instances using the parent field for epics.
+		Parent string
+		// EpicLink is the key of the epic, set through the Epic Link
+		// custom field.
+		EpicLink string
+		// CustomFields sets further fields by name or id, see
+		// resolveCustomFields.
+		CustomFields map[string]interface{}
+		// Format is the format of Description, see markup.ToWiki.
+		Format string
+	}
+
 	IssueFields struct {
-		Project     Project  `json:"project"`
-		Summary     string   `json:"summary"`
-		IssueType   Type     `json:"issuetype"`
-		Description string   `json:"description"`
-		Labels      []string `json:"labels"`
-		Priority    Type     `json:"priority"`
-		Reporter    Type     `json:"reporter"`
+		Project         Project     `json:"project"`
+		Summary         string      `json:"summary"`
+		IssueType       Type        `json:"issuetype"`
+		Description     interface{} `json:"description"`
+		Labels          []string    `json:"labels,omitempty"`
+		Priority        *Type       `json:"priority,omitempty"`
+		Reporter        *User       `json:"reporter,omitempty"`
+		Assignee        *User       `json:"assignee,omitempty"`
+		Components      []Type      `json:"components,omitempty"`
+		FixVersions     []Type      `json:"fixVersions,omitempty"`
+		AffectsVersions []Type      `json:"versions,omitempty"`
+		DueDate         string      `json:"duedate,omitempty"`
+		Environment     interface{} `json:"environment,omitempty"`
+		Parent          *LinkIssue  `json:"parent,omitempty"`
+		// Custom holds further fields keyed by field id, sent alongside
+		// the ones above.
+		Custom map[string]interface{} `json:"-"`
 	}
 
 	CustomIncIssueFields struct {
-		Project     Project  `json:"project"`
-		Summary     string   `json:"summary"`
-		IssueType   Type     `json:"issuetype"`
-		Description string   `json:"description"`
-		Labels      []string `json:"labels"`
+		Project     Project     `json:"project"`
+		Summary     string      `json:"summary"`
+		IssueType   Type        `json:"issuetype"`
+		Description interface{} `json:"description"`
+		Labels      []string    `json:"labels"`
+		// Custom holds further fields keyed by field id, sent alongside
+		// the ones above.
+		Custom map[string]interface{} `json:"-"`
 	}
 
 	Project struct {
@@ -87,10 +177,9 @@ type (
 		MaxResults   int            `json:"maxResults"`
 		TotalResults int            `json:"total"`
 		Issues       []domain.Issue `json:"issues"`
-	}
-
-	Assignee struct {
-		Name string `json:"name,omitempty"`
+		// NextPageToken points to the next page of the results on Jira
+		// Cloud, see SearchIssuesPage. It is empty on the last page.
+		NextPageToken string `json:"nextPageToken,omitempty"`
 	}
 
 	LinkIssueRequest struct {
@@ -117,111 +206,329 @@ type (
 	}
 )
 
-func CommentIssue(issueId, comment string) (domain.Issue, error) {
-	var issue domain.Issue
-	b, err := json.Marshal(Comment{comment})
+// MarshalJSON sends the custom fields next to the system ones.
+func (f IssueFields) MarshalJSON() ([]byte, error) {
+	type issueFields IssueFields
+	b, err := json.Marshal(issueFields(f))
 	if err != nil {
-		return issue, err
+		return nil, err
 	}
+	return withCustomFields(b, f.Custom)
+}
 
-	path := fmt.Sprintf("/rest/api/2/issue/%s/comment", issueId)
-	request, err := constructPostRequest(path, string(b))
+// MarshalJSON sends the custom fields next to the system ones.
+func (f CustomIncIssueFields) MarshalJSON() ([]byte, error) {
+	type customIncIssueFields CustomIncIssueFields
+	b, err := json.Marshal(customIncIssueFields(f))
 	if err != nil {
-		return issue, err
+		return nil, err
 	}
+	return withCustomFields(b, f.Custom)
+}
 
-	statusCode, err := SendRequest(request, &issue)
-	if statusCode != http.StatusCreated {
-		err = fmt.Errorf("issueId=%s : statusCode=%d", issueId, statusCode)
-		return domain.Issue{}, err
+// withCustomFields adds custom to the JSON object fields.
+func withCustomFields(fields []byte, custom map[string]interface{}) ([]byte, error) {
+	if len(custom) == 0 {
+		return fields, nil
+	}
+	merged := map[string]interface{}{}
+	if err := json.Unmarshal(fields, &merged); err != nil {
+		return nil, err
+	}
+	for id, value := range custom {
+		merged[id] = value
 	}
+	return json.Marshal(merged)
+}
+
+// New returns a Client for the Jira instance described by config.
+func New(config Config, opts ...Option) (*Client, error) {
+	tlsConfig, err := newTLSConfig(config.TLS)
 	if err != nil {
-		err = fmt.Errorf("issueId=%s : err=%v", issueId, err)
-		return domain.Issue{}, err
+		return nil, err
+	}
+	if err := config.Incident.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateDeployment(config.Deployment); err != nil {
+		return nil, err
 	}
 
-	return issue, nil
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	if config.Auth == nil {
+		config.Auth = Anonymous{}
+	}
+
+	c := &Client{
+		config:     config,
+		httpClient: &http.Client{Transport: transport},
+		limiter:    newLimiter(config.RateLimit),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
-func GetIssueInfo(issueId string) (domain.Issue, error) {
+// WithHTTPClient makes the Client send its requests through httpClient
+// instead of the default one.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Host returns the base url of the Jira instance, without a trailing slash.
+func (c *Client) Host() string {
+	return strings.TrimSuffix(c.config.Host, "/")
+}
+
+// BrowseURL returns the base url of the links to issues, without a trailing
+// slash: Config.BrowseURL if set, else the base url of the site if detected,
+// else Host. The site and Host differ when Host is the url of the OAuth 2.0
+// API, https://api.atlassian.com/ex/jira/<cloud id>.
+func (c *Client) BrowseURL() string {
+	if c.config.BrowseURL != "" {
+		return strings.TrimSuffix(c.config.BrowseURL, "/")
+	}
+	if baseURL, ok := c.baseURL.Load().(string); ok {
+		return baseURL
+	}
+	return c.Host()
+}
+
+// CommentIssue adds a comment to the issue issueId and returns it.
+func (c *Client) CommentIssue(issueId, comment string) (domain.Comment, error) {
+	return c.AddComment(issueId, NewComment{Body: comment})
+}
+
+// AddComment adds comment to the issue issueId and returns it.
+func (c *Client) AddComment(issueId string, comment NewComment) (domain.Comment, error) {
+	var created domain.Comment
+	if err := markup.CheckFormat(comment.Format); err != nil {
+		return created, fmt.Errorf("issueId=%s : %w", issueId, err)
+	}
+	body := c.richText(comment.Body, comment.Format)
+	if comment.ResolveMentions {
+		var err error
+		if body, err = c.resolveMentions(body); err != nil {
+			return created, fmt.Errorf("issueId=%s : %w", issueId, err)
+		}
+	}
+	b, err := json.Marshal(Comment{Body: body, Visibility: comment.Visibility})
+	if err != nil {
+		return created, err
+	}
+
+	path := fmt.Sprintf("/rest/api/2/issue/%s/comment", issueId)
+	request, err := c.newRequest(http.MethodPost, path, b)
+	if err != nil {
+		return created, err
+	}
+
+	if err := c.sendRequest(markRetryable(request), &created); err != nil {
+		return domain.Comment{}, fmt.Errorf("issueId=%s : %w", issueId, err)
+	}
+
+	return created, nil
+}
+
+func (c *Client) GetIssueInfo(issueId string) (domain.Issue, error) {
 	var issue domain.Issue
 	path := fmt.Sprintf("/rest/api/2/issue/%s", issueId)
 
-	request, err := constructGetRequest(path)
+	request, err := c.newRequest(http.MethodGet, path, nil)
 	if err != nil {
 		return issue, err
 	}
 
-	statusCode, err := SendRequest(request, &issue)
-	if statusCode != http.StatusOK {
-		return domain.Issue{}, fmt.Errorf("issueId=%s : statusCode=%d", issueId, statusCode)
-	}
-	if err != nil {
-		return domain.Issue{}, fmt.Errorf("issueId=%s : err=%s", issueId, err)
+	if err := c.sendRequest(request, &issue); err != nil {
+		return domain.Issue{}, fmt.Errorf("issueId=%s : %w", issueId, err)
 	}
 
 	return issue, nil
 }
 
-func CreateIssue(project, issueType, summary string, description string, priority string, reporter string) (domain.Issue, error) {
+// CreateIssue creates an issue.
+func (c *Client) CreateIssue(newIssue NewIssue) (domain.Issue, error) {
 	var issue domain.Issue
-	issueRequest := Issue{
-		Fields: IssueFields{
-			Project:     Project{Key: strings.TrimSpace(project)},
-			Priority:    Type{Name: strings.TrimSpace(priority)},
-			Summary:     summary,
-			IssueType:   Type{Name: issueType},
-			Description: description,
-			Reporter:    Type{Name: strings.TrimSpace(reporter)},
-		}}
-	b, err := json.Marshal(issueRequest)
+	fields, err := c.issueFields(newIssue)
+	if err != nil {
+		return issue, fmt.Errorf("issueSummary='%s' : %w", newIssue.Summary, err)
+	}
+	if err := c.validateCreate(fields); err != nil {
+		return issue, fmt.Errorf("issueSummary='%s' : %w", newIssue.Summary, err)
+	}
+	b, err := json.Marshal(Issue{Fields: fields})
 	if err != nil {
 		return issue, err
 	}
 
 	path := "/rest/api/2/issue/"
-	request, err := constructPostRequest(path, string(b))
+	request, err := c.newRequest(http.MethodPost, path, b)
 	if err != nil {
 		return issue, err
 	}
-	statusCode, err := SendRequest(request, &issue)
-	if statusCode != http.StatusCreated {
-		return domain.Issue{}, fmt.Errorf("issueSummary='%s' : statusCode=%d", summary, statusCode)
+	if err := c.sendRequest(markRetryable(request), &issue); err != nil {
+		return domain.Issue{}, fmt.Errorf("issueSummary='%s' : %w", newIssue.Summary, err)
 	}
+	return issue, nil
+}
+
+// issueFields converts newIssue to the fields Jira expects, resolving its
+// custom fields.
+func (c *Client) issueFields(newIssue NewIssue) (IssueFields, error) {
+	if err := markup.CheckFormat(newIssue.Format); err != nil {
+		return IssueFields{}, &ValidationError{
+			Project:     newIssue.Project,
+			IssueType:   newIssue.IssueType,
+			FieldErrors: map[string]string{"format": err.Error()},
+		}
+	}
+	customFields := newIssue.CustomFields
+	if newIssue.EpicLink != "" {
+		customFields = make(map[string]interface{}, len(newIssue.CustomFields)+1)
+		customFields[epicLinkField] = strings.TrimSpace(newIssue.EpicLink)
+		for name, value := range newIssue.CustomFields {
+			customFields[name] = value
+		}
+	}
+	custom, err := c.resolveCustomFields(customFields)
 	if err != nil {
-		err = fmt.Errorf("issueSummary=%s : err=%v", summary, err)
-		return domain.Issue{}, err
+		return IssueFields{}, err
+	}
+
+	fields := IssueFields{
+		Project:         Project{Key: strings.TrimSpace(newIssue.Project)},
+		Summary:         newIssue.Summary,
+		IssueType:       Type{Name: newIssue.IssueType},
+		Description:     c.richText(newIssue.Description, newIssue.Format),
+		Labels:          newIssue.Labels,
+		Components:      names(newIssue.Components),
+		FixVersions:     names(newIssue.FixVersions),
+		AffectsVersions: names(newIssue.AffectsVersions),
+		Custom:          custom,
+	}
+	// Jira Cloud rejects users without an account id
+	if reporter := strings.TrimSpace(newIssue.Reporter); reporter != "" || !c.isCloud() {
+		user, err := c.userRef(reporter, "")
+		if err != nil {
+			return IssueFields{}, userFieldError(newIssue, "reporter", err)
+		}
+		fields.Reporter = &user
+	}
+	if assignee := strings.TrimSpace(newIssue.Assignee); assignee != "" {
+		user, err := c.userRef(assignee, "")
+		if err != nil {
+			return IssueFields{}, userFieldError(newIssue, "assignee", err)
+		}
+		fields.Assignee = &user
+	}
+	if priority := strings.TrimSpace(newIssue.Priority); priority != "" {
+		fields.Priority = &Type{Name: priority}
+	}
+	if newIssue.Environment != "" {
+		fields.Environment = c.richText(newIssue.Environment, markup.Wiki)
+	}
+	if parent := strings.TrimSpace(newIssue.Parent); parent != "" {
+		fields.Parent = &LinkIssue{Key: parent}
+	}
+	if newIssue.DueDate != "" {
+		dueDate, err := parseTime(strings.TrimSpace(newIssue.DueDate))
+		if err != nil {
+			return IssueFields{}, &ValidationError{
+				Project:     newIssue.Project,
+				IssueType:   newIssue.IssueType,
+				FieldErrors: map[string]string{"duedate": err.Error()},
+			}
+		}
+		fields.DueDate = dueDate.Format(jiraDateFormat)
+	}
+	return fields, nil
+}
+
+// userFieldError reports the users that cannot be found as invalid values of
+// field, and passes other errors on.
+func userFieldError(newIssue NewIssue, field string, err error) error {
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+	return &ValidationError{
+		Project:     newIssue.Project,
+		IssueType:   newIssue.IssueType,
+		FieldErrors: map[string]string{field: err.Error()},
 	}
-	return issue, nil
+}
+
+// hasLabel tells whether labels contain label.
+func hasLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// names refers to components or versions by name.
+func names(values []string) []Type {
+	if len(values) == 0 {
+		return nil
+	}
+	types := make([]Type, len(values))
+	for i, value := range values {
+		types[i] = Type{Name: strings.TrimSpace(value)}
+	}
+	return types
 }
 
 // CreateCustomIssue sends create issue API call to JIRA https://tinyurl.com/mr45wbwf (docs)
 // Receives set of arguments to compile REST call body and returns JSON struct of response
-func CreateCustomIssue(project, issueType, summary, desc string, labels []string) (CreateIssueAPIResponse, error) {
-	// var issue domain.Issue
+// The ServiceNow incident, if any, is recorded as set by Config.Incident, except
+// for remote links which are added by LinkIncident. format is the format of
+// desc, see markup.ToWiki.
+func (c *Client) CreateCustomIssue(project, issueType, summary, desc, format, incident string, labels []string) (CreateIssueAPIResponse, error) {
+	if err := markup.CheckFormat(format); err != nil {
+		return CreateIssueAPIResponse{}, &ValidationError{Project: project, IssueType: issueType, FieldErrors: map[string]string{"format": err.Error()}}
+	}
+	incidentLabels, custom, err := c.incidentFields(incident)
+	if err != nil {
+		return CreateIssueAPIResponse{}, fmt.Errorf("issueSummary='%s' : %w", summary, err)
+	}
+	for _, label := range incidentLabels {
+		// the incident may be the dedupeKey, which labels hold already
+		if !hasLabel(labels, label) {
+			labels = append(labels, label)
+		}
+	}
 	issueRequest := CustomIncIssue{
 		Fields: CustomIncIssueFields{
 			Project:     Project{Key: project},
 			Summary:     summary,
 			IssueType:   Type{Name: issueType},
-			Description: desc,
+			Description: c.richText(desc, format),
 			Labels:      labels,
+			Custom:      custom,
 		}}
+	if err := c.validateCreate(issueRequest.Fields); err != nil {
+		return CreateIssueAPIResponse{}, fmt.Errorf("issueSummary='%s' : %w", summary, err)
+	}
 	b, err := json.Marshal(issueRequest)
 	if err != nil {
 		return CreateIssueAPIResponse{}, err
 	}
 
 	path := "/rest/api/2/issue/"
-	request, err := constructPostRequest(path, string(b))
+	request, err := c.newRequest(http.MethodPost, path, b)
 	if err != nil {
 		return CreateIssueAPIResponse{}, err
 	}
 
-	resp, err := SendCustomRequest(request) // resp is []byte
+	resp, err := c.sendCustomRequest(markRetryable(request)) // resp is []byte
 	if err != nil {
-		err = fmt.Errorf("issueSummary=%s : err=%v", summary, err)
-		return CreateIssueAPIResponse{}, err
+		return CreateIssueAPIResponse{}, fmt.Errorf("issueSummary='%s' : %w", summary, err)
 	}
 
 	jsresp := CreateIssueAPIResponse{} // JSON response which returned to command handler
@@ -240,7 +547,13 @@ func CreateCustomIssue(project, issueType, summary, desc string, labels []string
 
 }
 
-func SearchIssues(query string, startIndex int, maxResults int) (SearchResult, error) {
+// SearchIssues returns the issues matching query, from startIndex on. Jira
+// Cloud only pages searches by token, see SearchIssuesPage, so the issues
+// before startIndex are skipped there.
+func (c *Client) SearchIssues(query string, startIndex int, maxResults int) (SearchResult, error) {
+	if c.isCloud() {
+		return c.searchCloud(query, startIndex, maxResults)
+	}
 	var searchResult SearchResult
 
 	requestBody := newSearchRequestBody(query, startIndex, maxResults)
@@ -250,201 +563,171 @@ func SearchIssues(query string, startIndex int, maxResults int) (SearchResult, e
 	}
 
 	path := "/rest/api/2/search"
-	request, err := constructPostRequest(path, string(encodedBody))
+	request, err := c.newRequest(http.MethodPost, path, encodedBody)
 	if err != nil {
 		return searchResult, err
 	}
 
-	statusCode, err := SendRequest(request, &searchResult)
-	if err != nil {
-		err := fmt.Errorf("query='%s' : error=%v", query, err)
-		return searchResult, err
-	}
-	if statusCode != http.StatusOK {
-		err := fmt.Errorf("query='%s' : statusCode=%d", query, statusCode)
-		return searchResult, err
+	if err := c.sendRequest(markIdempotent(request), &searchResult); err != nil {
+		return searchResult, fmt.Errorf("query='%s' : %w", query, err)
 	}
 
 	return searchResult, nil
 }
 
-func AssignIssue(issueId, username string) error {
-	path := fmt.Sprintf("/rest/api/2/issue/%s/assignee", issueId)
-	b, err := json.Marshal(&Assignee{username})
+// AssignIssue assigns the issue issueId to username, which may be any
+// identity of a user FindUser resolves, see userRef. An empty username
+// unassigns the issue and -1 assigns it to the default assignee.
+func (c *Client) AssignIssue(issueId, username string) error {
+	user, err := c.userRef(username, issueId)
 	if err != nil {
-		return err
+		return fmt.Errorf("issueId=%s user=%s : %w", issueId, username, err)
 	}
-
-	req, err := constructPutRequest(path, string(b))
+	var body interface{} = struct {
+		Name string `json:"name,omitempty"`
+	}{user.Name}
+	if c.isCloud() {
+		// an empty account id unassigns the issue
+		body = map[string]interface{}{"accountId": nil}
+		if user.AccountID != "" {
+			body = user
+		}
+	}
+	b, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
-	httpCode, err := SendRequestWithoutResp(req)
+	path := fmt.Sprintf("/rest/api/2/issue/%s/assignee", issueId)
+	req, err := c.newRequest(http.MethodPut, path, b)
 	if err != nil {
 		return err
 	}
 
-	switch httpCode {
-	case http.StatusNoContent:
-		err = nil
-	case http.StatusBadRequest:
-		err = errors.New("invalid user representation")
-	case http.StatusUnauthorized:
-		err = errors.New("invalid permission to assign to issue")
-	case http.StatusNotFound:
-		err = errors.New("issue or user does not exist")
-	default:
-		err = fmt.Errorf("unsupported status code %d", httpCode)
+	if err := c.sendRequestWithoutResp(req); err != nil {
+		return fmt.Errorf("issueId=%s user=%s : %w", issueId, username, c.assignError(issueId, err))
 	}
+	return nil
+}
 
+// assignError tells which of the issue and the user was not found when Jira
+// refuses an assignment with a 404, which it returns for both.
+func (c *Client) assignError(issueId string, err error) error {
+	var jiraErr *JiraError
+	if !errors.As(err, &jiraErr) || jiraErr.StatusCode != http.StatusNotFound {
+		return err
+	}
+	request, requestErr := c.newRequest(http.MethodGet, fmt.Sprintf("/rest/api/2/issue/%s?fields=status", issueId), nil)
+	if requestErr != nil {
+		return err
+	}
+	var issueErr *JiraError
+	switch checkErr := c.sendRequestWithoutResp(request); {
+	case checkErr == nil:
+		return fmt.Errorf("%w: the user does not exist or cannot be assigned the issue", ErrUserNotFound)
+	case errors.As(checkErr, &issueErr) && issueErr.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("issue %s does not exist or you cannot see it : %w", issueId, err)
+	}
 	return err
 }
 
-func LinkIssues(inwardKey, outwardKey, linkType string) error {
+func (c *Client) LinkIssues(inwardKey, outwardKey, linkType string) error {
+	return c.linkIssues(inwardKey, outwardKey, linkType, &Comment{Body: c.richText("Link related issues!", markup.Wiki)})
+}
+
+// linkIssues links inwardKey to outwardKey, e.g. "inwardKey blocks
+// outwardKey" for the Blocks link type, commenting the link when comment is
+// not nil.
+func (c *Client) linkIssues(inwardKey, outwardKey, linkType string, comment *Comment) error {
 	path := "/rest/api/2/issueLink"
-	linkReq := LinkIssueRequest{
+	linkReq := struct {
+		LinkType IssueLink `json:"type"`
+		Inward   LinkIssue `json:"inwardIssue"`
+		Outward  LinkIssue `json:"outwardIssue"`
+		Comment  *Comment  `json:"comment,omitempty"`
+	}{
 		LinkType: IssueLink{Name: linkType},
 		Inward:   LinkIssue{inwardKey},
 		Outward:  LinkIssue{outwardKey},
-		Comment:  Comment{"Link related issues!"},
+		Comment:  comment,
 	}
 	b, err := json.Marshal(linkReq)
 	if err != nil {
 		return err
 	}
 
-	httpReq, err := constructPostRequest(path, string(b))
+	httpReq, err := c.newRequest(http.MethodPost, path, b)
 	if err != nil {
 		return err
 	}
 
-	httpCode, err := SendRequestWithoutResp(httpReq)
-	if err != nil {
-		return err
+	if err := c.sendRequestWithoutResp(markRetryable(httpReq)); err != nil {
+		return fmt.Errorf("inward=%s outward=%s type='%s' : %w", inwardKey, outwardKey, linkType, err)
 	}
-
-	return checkHttpCode(httpCode, linkReq.Body)
+	return nil
 }
 
-func GetLink(linkId string) (*LinkIssueRequest, error) {
+func (c *Client) GetLink(linkId string) (*LinkIssueRequest, error) {
 	path := fmt.Sprintf("/rest/api/2/issueLink/%s", linkId)
-	httpReq, err := constructGetRequest(path)
+	httpReq, err := c.newRequest(http.MethodGet, path, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	link := &LinkIssueRequest{}
-	httpCode, err := SendRequest(httpReq, &link)
-
-	return link, checkHttpCode(httpCode, linkId)
+	if err := c.sendRequest(httpReq, link); err != nil {
+		return link, fmt.Errorf("linkId=%s : %w", linkId, err)
+	}
+	return link, nil
 }
 
-//TODO: get rid of all of those separate construct methods...
-func DeleteLink(linkId string) error {
+func (c *Client) DeleteLink(linkId string) error {
 	path := fmt.Sprintf("/rest/api/2/issueLink/%s", linkId)
-	httpReq, err := constructDeleteRequest(path)
+	httpReq, err := c.newRequest(http.MethodDelete, path, nil)
 	if err != nil {
 		return err
 	}
 
-	httpCode, err := SendRequestWithoutResp(httpReq)
-	return checkHttpCode(httpCode, linkId)
-}
-
-//TODO: change error msg to be more generic and use in other funcs
-func checkHttpCode(httpCode int, in string) error {
-	if 200 <= httpCode && httpCode <= 208 {
-		return nil
-	}
-
-	var err error
-	switch httpCode {
-	case http.StatusBadRequest:
-		err = fmt.Errorf("failed to create issue with comment %s", in)
-	case http.StatusUnauthorized:
-		err = errors.New("invalid permission to link issues")
-	case http.StatusInternalServerError:
-		err = errors.New("error occurred when creating link or comment")
-	case http.StatusNotFound:
-		err = errors.New("could not find issue or invalid link type specified")
-	default:
-		err = fmt.Errorf("unsupported status code %d found", httpCode)
+	if err := c.sendRequestWithoutResp(httpReq); err != nil {
+		return fmt.Errorf("linkId=%s : %w", linkId, err)
 	}
-
-	return err
+	return nil
 }
 
+// searchFields are the fields of the issues searches return.
+var searchFields = []string{"summary", "assignee", "labels", "status", "description", "priority", "issuetype"}
+
 func newSearchRequestBody(query string, startIndex int, maxResults int) SearchRequestType {
 	return SearchRequestType{
 		query,
 		startIndex,
 		maxResults,
-		[]string{"summary", "assignee", "labels", "status", "description", "priority"},
+		searchFields,
 	}
 }
 
-func constructGetRequest(path string) (*http.Request, error) {
-	request, err := http.NewRequest(http.MethodGet, getUrl(path), nil)
-	if err != nil {
-		return request, err
-	}
-
-	request.Header.Set("Accept", "application/json")
-	if JiraConfig.Username != "" {
-		request.SetBasicAuth(JiraConfig.Username, JiraConfig.Password)
+// newRequest builds a request against the client's Jira host. A nil body
+// produces a request without a payload, anything else is sent as JSON.
+func (c *Client) newRequest(method, path string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
 	}
 
-	return request, err
-}
-
-func constructDeleteRequest(path string) (*http.Request, error) {
-	request, err := http.NewRequest(http.MethodDelete, getUrl(path), nil)
+	request, err := http.NewRequest(method, c.getUrl(c.apiPath(path)), reader)
 	if err != nil {
 		return request, err
 	}
 
-	request.Header.Set("Accept", "application/json")
-	if JiraConfig.Username != "" {
-		request.SetBasicAuth(JiraConfig.Username, JiraConfig.Password)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
 	}
-
-	return request, err
-}
-
-func constructPostRequest(path, data string) (*http.Request, error) {
-	request, err := http.NewRequest(http.MethodPost, getUrl(path), bytes.NewBuffer([]byte(data)))
-	if err != nil {
-		return request, err
-	}
-
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("Accept", "application/json")
-
-	if JiraConfig.Username != "" {
-		request.SetBasicAuth(JiraConfig.Username, JiraConfig.Password)
-	}
-	return request, err
-}
-
-func constructPutRequest(path, data string) (*http.Request, error) {
-	request, err := http.NewRequest(http.MethodPut, getUrl(path), bytes.NewBuffer([]byte(data)))
-	if err != nil {
-		return request, err
-	}
-
-	request.Header.Set("Content-LinkType", "application/json")
 	request.Header.Set("Accept", "application/json")
 
-	if JiraConfig.Username != "" {
-		request.SetBasicAuth(JiraConfig.Username, JiraConfig.Password)
-	}
-
-	return request, err
-
+	return request, nil
 }
 
-func getUrl(path string) string {
+func (c *Client) getUrl(path string) string {
 	path = strings.TrimPrefix(path, "/")
-	return fmt.Sprintf("%s/%s", strings.TrimSuffix(JiraConfig.Host, "/"), path)
+	return fmt.Sprintf("%s/%s", c.Host(), path)
 }
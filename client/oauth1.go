@@ -0,0 +1,154 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OAuth1 signs requests with OAuth 1.0a RSA-SHA1, as expected by Jira
+// Server application links. The access token is obtained out of band.
+type OAuth1 struct {
+	ConsumerKey string
+	AccessToken string
+	PrivateKey  *rsa.PrivateKey
+}
+
+// LoadRSAPrivateKey reads a PEM encoded PKCS#1 or PKCS#8 RSA private key.
+func LoadRSAPrivateKey(file string) (*rsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read private key: %v", err)
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func (o OAuth1) Authenticate(request *http.Request) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"oauth_consumer_key":     o.ConsumerKey,
+		"oauth_token":            o.AccessToken,
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_version":          "1.0",
+	}
+
+	digest := sha1.Sum([]byte(oauth1BaseString(request, params)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, o.PrivateKey, crypto.SHA1, digest[:])
+	if err != nil {
+		return fmt.Errorf("cannot sign request: %v", err)
+	}
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(signature)
+
+	var header []string
+	for _, k := range sortedKeys(params) {
+		header = append(header, fmt.Sprintf(`%s="%s"`, k, oauthEscape(params[k])))
+	}
+	request.Header.Set("Authorization", "OAuth "+strings.Join(header, ", "))
+	return nil
+}
+
+// oauth1BaseString builds the signature base string of RFC 5849 section
+// 3.4.1. Request bodies are JSON, so only query and oauth parameters count.
+func oauth1BaseString(request *http.Request, oauthParams map[string]string) string {
+	var pairs []string
+	for k, values := range request.URL.Query() {
+		for _, v := range values {
+			pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+		}
+	}
+	for k, v := range oauthParams {
+		pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+	}
+	sort.Strings(pairs)
+
+	baseURL := url.URL{
+		Scheme: strings.ToLower(request.URL.Scheme),
+		Host:   strings.ToLower(request.URL.Host),
+		Path:   request.URL.EscapedPath(),
+	}
+	if port := baseURL.Port(); (baseURL.Scheme == "http" && port == "80") || (baseURL.Scheme == "https" && port == "443") {
+		baseURL.Host = baseURL.Hostname()
+	}
+
+	return strings.Join([]string{
+		strings.ToUpper(request.Method),
+		oauthEscape(baseURL.String()),
+		oauthEscape(strings.Join(pairs, "&")),
+	}, "&")
+}
+
+// oauthEscape percent-encodes everything but the RFC 3986 unreserved
+// characters, as required by RFC 5849 section 3.6.
+func oauthEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestOAuth1SignsRequests(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var verifyErr error
+	var params map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = parseOAuthHeader(t, r.Header.Get("Authorization"))
+		signature, _ := base64.StdEncoding.DecodeString(params["oauth_signature"])
+		delete(params, "oauth_signature")
+
+		r.URL.Scheme, r.URL.Host = "http", r.Host
+		digest := sha1.Sum([]byte(oauth1BaseString(r, params)))
+		verifyErr = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], signature)
+		okHandler(w, r)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Host: server.URL, Auth: OAuth1{ConsumerKey: "flyte", AccessToken: "token", PrivateKey: key}})
+	_, err = c.GetTransitions("TEST-1")
+	require.NoError(t, err)
+
+	assert.NoError(t, verifyErr)
+	assert.Equal(t, "flyte", params["oauth_consumer_key"])
+	assert.Equal(t, "token", params["oauth_token"])
+	assert.Equal(t, "RSA-SHA1", params["oauth_signature_method"])
+	assert.Equal(t, "1.0", params["oauth_version"])
+}
+
+func TestOAuth1BaseString(t *testing.T) {
+	request, err := http.NewRequest(http.MethodGet, "HTTPS://Jira.Example.com:443/rest/api/2/search?jql=a%20b&startAt=0", nil)
+	require.NoError(t, err)
+
+	base := oauth1BaseString(request, map[string]string{"oauth_nonce": "n~1"})
+	assert.Equal(t, "GET&https%3A%2F%2Fjira.example.com%2Frest%2Fapi%2F2%2Fsearch&jql%3Da%2520b%26oauth_nonce%3Dn~1%26startAt%3D0", base)
+}
+
+func TestLoadRSAPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	dir := tempDir(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	for _, file := range []string{
+		writePEM(t, dir, "pkcs1.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+		writePEM(t, dir, "pkcs8.pem", "PRIVATE KEY", pkcs8),
+	} {
+		loaded, err := LoadRSAPrivateKey(file)
+		require.NoError(t, err)
+		assert.True(t, key.Equal(loaded))
+	}
+}
+
+func parseOAuthHeader(t *testing.T, header string) map[string]string {
+	params := map[string]string{}
+	for _, m := range regexp.MustCompile(`(\w+)="([^"]*)"`).FindAllStringSubmatch(header, -1) {
+		v, err := url.PathUnescape(m[2])
+		require.NoError(t, err)
+		params[m[1]] = v
+	}
+	return params
+}
@@ -0,0 +1,203 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOAuth2TokenURL is the Atlassian token endpoint used for Jira Cloud
+// OAuth 2.0 (3LO) apps.
+const DefaultOAuth2TokenURL = "https://auth.atlassian.com/oauth/token"
+
+// tokenExpiryMargin refreshes access tokens a little before they expire so
+// a request is not sent with a token that lapses on the way.
+const tokenExpiryMargin = 30 * time.Second
+
+type (
+	// OAuth2Config describes an OAuth 2.0 app and where its tokens live.
+	OAuth2Config struct {
+		TokenURL     string
+		ClientID     string
+		ClientSecret string
+		// RefreshTokenFile keeps the current refresh token. Jira Cloud
+		// rotates refresh tokens, so the file is rewritten after every
+		// refresh and read back on restart.
+		RefreshTokenFile string
+		// RefreshToken seeds the token file when it does not exist yet.
+		RefreshToken string
+		// AuthorizationCode and RedirectURI are exchanged for the first
+		// tokens when no refresh token is available at all.
+		AuthorizationCode string
+		RedirectURI       string
+		// HTTPClient talks to the token endpoint, http.DefaultClient when nil.
+		HTTPClient *http.Client
+	}
+
+	// OAuth2 authenticates requests with OAuth 2.0 access tokens obtained
+	// through the authorization code grant and refreshed when they expire.
+	OAuth2 struct {
+		config OAuth2Config
+
+		mu           sync.Mutex
+		accessToken  string
+		expiry       time.Time
+		refreshToken string
+	}
+
+	oauth2TokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+		Description  string `json:"error_description"`
+	}
+)
+
+// NewOAuth2 returns an OAuth2 authenticator, reading the refresh token from
+// config.RefreshTokenFile when it exists.
+func NewOAuth2(config OAuth2Config) (*OAuth2, error) {
+	if config.TokenURL == "" {
+		config.TokenURL = DefaultOAuth2TokenURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	o := &OAuth2{config: config, refreshToken: config.RefreshToken}
+	if config.RefreshTokenFile != "" {
+		b, err := ioutil.ReadFile(config.RefreshTokenFile)
+		switch {
+		case err == nil:
+			o.refreshToken = strings.TrimSpace(string(b))
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("cannot read refresh token file: %v", err)
+		}
+	}
+
+	if o.refreshToken == "" && config.AuthorizationCode == "" {
+		return nil, errors.New("oauth2 needs either a refresh token or an authorization code")
+	}
+	return o, nil
+}
+
+func (o *OAuth2) Authenticate(request *http.Request) error {
+	token, err := o.token()
+	if err != nil {
+		return fmt.Errorf("cannot get oauth2 access token: %w", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// token returns a valid access token, refreshing it if needed. Concurrent
+// callers wait for a single refresh instead of each rotating the token.
+func (o *OAuth2) token() (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.accessToken != "" && time.Now().Add(tokenExpiryMargin).Before(o.expiry) {
+		return o.accessToken, nil
+	}
+
+	params := url.Values{
+		"client_id":     {o.config.ClientID},
+		"client_secret": {o.config.ClientSecret},
+	}
+	if o.refreshToken != "" {
+		params.Set("grant_type", "refresh_token")
+		params.Set("refresh_token", o.refreshToken)
+	} else {
+		params.Set("grant_type", "authorization_code")
+		params.Set("code", o.config.AuthorizationCode)
+		params.Set("redirect_uri", o.config.RedirectURI)
+	}
+
+	resp, err := o.requestToken(params)
+	if err != nil {
+		return "", err
+	}
+
+	o.accessToken = resp.AccessToken
+	o.expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if resp.RefreshToken != "" && resp.RefreshToken != o.refreshToken {
+		o.refreshToken = resp.RefreshToken
+		if err := o.saveRefreshToken(); err != nil {
+			return "", err
+		}
+	}
+	return o.accessToken, nil
+}
+
+func (o *OAuth2) requestToken(params url.Values) (oauth2TokenResponse, error) {
+	var resp oauth2TokenResponse
+	r, err := o.config.HTTPClient.PostForm(o.config.TokenURL, params)
+	if err != nil {
+		return resp, err
+	}
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil && r.StatusCode == http.StatusOK {
+		return resp, fmt.Errorf("cannot decode token response: %v", err)
+	}
+	if r.StatusCode != http.StatusOK {
+		if resp.Error != "" {
+			return resp, fmt.Errorf("token endpoint returned %s: %s %s", r.Status, resp.Error, resp.Description)
+		}
+		return resp, fmt.Errorf("token endpoint returned %s", r.Status)
+	}
+	if resp.AccessToken == "" {
+		return resp, errors.New("token endpoint returned no access token")
+	}
+	return resp, nil
+}
+
+// saveRefreshToken atomically replaces the token file, so a crash halfway
+// through never leaves us without a usable refresh token.
+func (o *OAuth2) saveRefreshToken() error {
+	if o.config.RefreshTokenFile == "" {
+		return nil
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(o.config.RefreshTokenFile), ".refresh-token")
+	if err != nil {
+		return fmt.Errorf("cannot save refresh token: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(o.refreshToken); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot save refresh token: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot save refresh token: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), o.config.RefreshTokenFile); err != nil {
+		return fmt.Errorf("cannot save refresh token: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fakeTokenEndpoint mimics the Atlassian token endpoint: it exchanges a
+// single authorization code and rotates the refresh token on every use.
+type fakeTokenEndpoint struct {
+	mu           sync.Mutex
+	code         string
+	refreshToken string
+	issued       int
+	expiresIn    int
+}
+
+func (f *fakeTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.FormValue("client_id") != "app" || r.FormValue("client_secret") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		if f.code == "" || r.FormValue("code") != f.code {
+			f.invalidGrant(w)
+			return
+		}
+		f.code = ""
+	case "refresh_token":
+		if r.FormValue("refresh_token") != f.refreshToken {
+			f.invalidGrant(w)
+			return
+		}
+	default:
+		f.invalidGrant(w)
+		return
+	}
+
+	f.issued++
+	f.refreshToken = fmt.Sprintf("refresh-%d", f.issued)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  fmt.Sprintf("access-%d", f.issued),
+		"refresh_token": f.refreshToken,
+		"expires_in":    f.expiresIn,
+	})
+}
+
+func (f *fakeTokenEndpoint) invalidGrant(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Unknown or invalid refresh token."})
+}
+
+// bearerRecorder is a Jira stand-in remembering the bearer token of each request.
+func bearerRecorder(t *testing.T) (*httptest.Server, *[]string) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		okHandler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &tokens
+}
+
+func TestOAuth2ExchangesAuthorizationCodeAndRotatesRefreshToken(t *testing.T) {
+	endpoint := &fakeTokenEndpoint{code: "auth-code", expiresIn: 0}
+	tokenServer := httptest.NewServer(endpoint)
+	defer tokenServer.Close()
+	jira, tokens := bearerRecorder(t)
+
+	tokenFile := filepath.Join(tempDir(t), "refresh-token")
+	config := OAuth2Config{
+		TokenURL:          tokenServer.URL,
+		ClientID:          "app",
+		ClientSecret:      "secret",
+		RefreshTokenFile:  tokenFile,
+		AuthorizationCode: "auth-code",
+		RedirectURI:       "https://flyte.example.com/callback",
+	}
+	auth, err := NewOAuth2(config)
+	require.NoError(t, err)
+	c := newTestClient(t, Config{Host: jira.URL, Auth: auth})
+
+	// tokens expire immediately, so every request needs a refresh
+	for i := 0; i < 2; i++ {
+		_, err = c.GetTransitions("TEST-1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"Bearer access-1", "Bearer access-2"}, *tokens)
+
+	saved, err := ioutil.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-2", string(saved))
+
+	// a restarted pack picks the rotated refresh token up from the file
+	restarted, err := NewOAuth2(config)
+	require.NoError(t, err)
+	c = newTestClient(t, Config{Host: jira.URL, Auth: restarted})
+	_, err = c.GetTransitions("TEST-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-3", (*tokens)[2])
+}
+
+func TestOAuth2ReusesValidAccessToken(t *testing.T) {
+	endpoint := &fakeTokenEndpoint{refreshToken: "refresh-0", expiresIn: 3600}
+	tokenServer := httptest.NewServer(endpoint)
+	defer tokenServer.Close()
+	jira, tokens := bearerRecorder(t)
+
+	auth, err := NewOAuth2(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "app", ClientSecret: "secret", RefreshToken: "refresh-0"})
+	require.NoError(t, err)
+	c := newTestClient(t, Config{Host: jira.URL, Auth: auth})
+
+	for i := 0; i < 3; i++ {
+		_, err = c.GetTransitions("TEST-1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"Bearer access-1", "Bearer access-1", "Bearer access-1"}, *tokens)
+	assert.Equal(t, 1, endpoint.issued)
+}
+
+func TestOAuth2InvalidRefreshToken(t *testing.T) {
+	tokenServer := httptest.NewServer(&fakeTokenEndpoint{refreshToken: "refresh-0"})
+	defer tokenServer.Close()
+	jira, tokens := bearerRecorder(t)
+
+	auth, err := NewOAuth2(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "app", ClientSecret: "secret", RefreshToken: "revoked"})
+	require.NoError(t, err)
+	c := newTestClient(t, Config{Host: jira.URL, Auth: auth})
+
+	_, err = c.GetTransitions("TEST-1")
+	assert.EqualError(t, err, "issueId=TEST-1 : cannot get oauth2 access token: token endpoint returned 403 Forbidden: invalid_grant Unknown or invalid refresh token.")
+	assert.Empty(t, *tokens)
+}
+
+func TestOAuth2NeedsInitialCredentials(t *testing.T) {
+	_, err := NewOAuth2(OAuth2Config{ClientID: "app", RefreshTokenFile: filepath.Join(tempDir(t), "missing")})
+	assert.EqualError(t, err, "oauth2 needs either a refresh token or an authorization code")
+}
@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is wrapped by the error returned when a request could not
+// be sent within RateLimitConfig.MaxWait because of the local limits.
+var ErrRateLimited = errors.New("rate limited locally")
+
+type (
+	// RateLimitConfig throttles the requests sent to a Jira instance so that
+	// bursts of commands do not get the whole instance throttled.
+	RateLimitConfig struct {
+		// RequestsPerSecond is the sustained request rate, 0 for no limit.
+		RequestsPerSecond float64
+		// Burst is how many requests may be sent at once after a quiet
+		// period. Defaults to 1 when a rate is set.
+		Burst int
+		// MaxInFlight caps the number of concurrent requests, 0 for no limit.
+		MaxInFlight int
+		// MaxWait is how long a request may queue for the limits above
+		// before it fails with ErrRateLimited.
+		MaxWait time.Duration
+	}
+
+	// limiter is a token bucket combined with a semaphore on requests in
+	// flight. A nil limiter lets every request through.
+	limiter struct {
+		rate     float64
+		burst    float64
+		maxWait  time.Duration
+		inFlight chan struct{}
+
+		mu     sync.Mutex
+		tokens float64
+		last   time.Time
+	}
+
+	// releasingBody frees a request slot once the response body is closed.
+	releasingBody struct {
+		io.ReadCloser
+		once    sync.Once
+		release func()
+	}
+)
+
+func newLimiter(config RateLimitConfig) *limiter {
+	if config.RequestsPerSecond <= 0 && config.MaxInFlight <= 0 {
+		return nil
+	}
+
+	l := &limiter{
+		rate:    config.RequestsPerSecond,
+		burst:   math.Max(1, float64(config.Burst)),
+		maxWait: config.MaxWait,
+		last:    time.Now(),
+	}
+	l.tokens = l.burst
+	if config.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+// acquire waits for a token and a free request slot. The returned function
+// gives the slot back and must be called once the request is done.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(l.maxWait)
+	if wait, ok := l.reserve(deadline); !ok {
+		return nil, fmt.Errorf("%w: request rate limit of %g/s reached", ErrRateLimited, l.rate)
+	} else if wait > 0 {
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+
+	release := func() { <-l.inFlight }
+	select {
+	case l.inFlight <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case l.inFlight <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: %d requests already in flight", ErrRateLimited, cap(l.inFlight))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// reserve takes a token from the bucket and returns how long to wait before
+// using it, or false when that would be past deadline.
+func (l *limiter) reserve(deadline time.Time) (time.Duration, bool) {
+	if l.rate <= 0 {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	var wait time.Duration
+	if l.tokens < 1 {
+		wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+	if wait > 0 && now.Add(wait).After(deadline) {
+		return 0, false
+	}
+	l.tokens--
+	return wait, true
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
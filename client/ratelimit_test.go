@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRejectsRequestsOverMaxWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL, RateLimit: RateLimitConfig{RequestsPerSecond: 1}})
+
+	_, err := c.GetTransitions("TEST-1")
+	require.NoError(t, err)
+
+	_, err = c.CommentIssue("TEST-1", "hello")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.EqualError(t, err, "issueId=TEST-1 : rate limited locally: request rate limit of 1/s reached")
+}
+
+func TestRateLimitQueuesRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL, RateLimit: RateLimitConfig{RequestsPerSecond: 20, MaxWait: time.Second}})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.GetTransitions("TEST-1")
+		require.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 90*time.Millisecond, "requests were not spaced out")
+}
+
+func TestMaxInFlight(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		okHandler(w, r)
+	}))
+	defer server.Close()
+	c := newTestClient(t, Config{Host: server.URL, RateLimit: RateLimitConfig{MaxInFlight: 1, MaxWait: 50 * time.Millisecond}})
+
+	first := make(chan error)
+	go func() {
+		_, err := c.GetTransitions("TEST-1")
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := c.GetTransitions("TEST-2")
+	assert.EqualError(t, err, "issueId=TEST-2 : rate limited locally: 1 requests already in flight")
+
+	close(unblock)
+	assert.NoError(t, <-first)
+
+	_, err = c.GetTransitions("TEST-3")
+	assert.NoError(t, err, "request slot was not released")
+}
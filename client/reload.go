@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ReloadableAuth is an Authenticator whose credentials can be replaced while
+// the pack is running, e.g. after a mounted secret was rotated. Requests
+// already sent keep the credentials they went out with, later requests and
+// retries use the new ones.
+type ReloadableAuth struct {
+	load    func() (Authenticator, error)
+	reload  sync.Mutex
+	current atomic.Value
+}
+
+// authenticatorBox gives atomic.Value the single concrete type it requires.
+type authenticatorBox struct {
+	Authenticator
+}
+
+// NewReloadableAuth returns a ReloadableAuth using the Authenticator built
+// by load, which is called again on every Reload.
+func NewReloadableAuth(load func() (Authenticator, error)) (*ReloadableAuth, error) {
+	a := &ReloadableAuth{load: load}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload swaps in freshly loaded credentials. When loading fails the current
+// credentials are kept.
+func (a *ReloadableAuth) Reload() error {
+	a.reload.Lock()
+	defer a.reload.Unlock()
+
+	auth, err := a.load()
+	if err != nil {
+		return err
+	}
+	a.current.Store(authenticatorBox{auth})
+	return nil
+}
+
+func (a *ReloadableAuth) Authenticate(request *http.Request) error {
+	return a.current.Load().(authenticatorBox).Authenticate(request)
+}
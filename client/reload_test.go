@@ -0,0 +1,94 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestReloadableAuthSwapsCredentials(t *testing.T) {
+	var mu sync.Mutex
+	var passwords []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+		mu.Lock()
+		passwords = append(passwords, password)
+		mu.Unlock()
+		okHandler(w, r)
+	}))
+	defer server.Close()
+
+	password := "old"
+	var loadErr error
+	auth, err := NewReloadableAuth(func() (Authenticator, error) {
+		return BasicAuth{Username: "flyte", Password: password}, loadErr
+	})
+	require.NoError(t, err)
+	c := newTestClient(t, Config{Host: server.URL, Auth: auth})
+
+	_, err = c.GetTransitions("TEST-1")
+	require.NoError(t, err)
+
+	password = "new"
+	require.NoError(t, auth.Reload())
+	_, err = c.GetTransitions("TEST-1")
+	require.NoError(t, err)
+
+	// a broken rotation keeps the working credentials
+	password, loadErr = "", errors.New("secret file is empty")
+	assert.EqualError(t, auth.Reload(), "secret file is empty")
+	_, err = c.GetTransitions("TEST-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"old", "new", "new"}, passwords)
+}
+
+func TestReloadableAuthDuringRequests(t *testing.T) {
+	auth, err := NewReloadableAuth(func() (Authenticator, error) {
+		return BearerToken{Token: "token"}, nil
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodGet, "http://jira.test", nil)
+			assert.NoError(t, auth.Authenticate(request))
+			assert.Equal(t, "Bearer token", request.Header.Get("Authorization"))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, auth.Reload())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReloadableAuthInitialLoadFails(t *testing.T) {
+	_, err := NewReloadableAuth(func() (Authenticator, error) {
+		return nil, errors.New("neither JIRA_PASSWORD nor JIRA_PASSWORD_FILE env. variable is set")
+	})
+	assert.EqualError(t, err, "neither JIRA_PASSWORD nor JIRA_PASSWORD_FILE env. variable is set")
+}
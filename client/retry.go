@@ -0,0 +1,256 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type (
+	// RetryConfig controls how requests rejected by Jira (429, 503, ...) or
+	// lost on the way are retried. The zero value disables retries.
+	RetryConfig struct {
+		// MaxRetries is the number of retries after the first attempt.
+		MaxRetries int
+		// BaseDelay is the backoff before the first retry, doubled for
+		// every following one and capped at MaxDelay.
+		BaseDelay time.Duration
+		MaxDelay  time.Duration
+		// MaxElapsed caps the total time spent on a single request,
+		// attempts and waits included: it is the deadline of the request
+		// and no retry is started that would exceed it.
+		MaxElapsed time.Duration
+	}
+
+	// RetryError is returned when a request still failed after being
+	// retried. Err describes the last failure.
+	RetryError struct {
+		Retries int
+		Reason  string
+		Err     error
+	}
+
+	retryMode int
+
+	retryModeKey struct{}
+)
+
+const (
+	// retryNever is the zero value: POST requests are not retried unless
+	// they are marked otherwise.
+	retryNever retryMode = iota
+	// retryOnRejection resends a request only when Jira explicitly refused
+	// it (429 or 503), i.e. it is known not to have been processed.
+	retryOnRejection
+	// retryAlways treats the request as idempotent.
+	retryAlways
+)
+
+// DefaultRetryConfig returns the retry settings used by the pack unless
+// overridden.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+		MaxElapsed: 30 * time.Second,
+	}
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (gave up after %d retries: %s)", e.Err, e.Retries, e.Reason)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// markIdempotent flags a request as safe to resend even though its method
+// is not idempotent, e.g. a read-only search sent as a POST.
+func markIdempotent(request *http.Request) *http.Request {
+	return withRetryMode(request, retryAlways)
+}
+
+// markRetryable flags a non-idempotent request as safe to resend when Jira
+// refused it without processing it.
+func markRetryable(request *http.Request) *http.Request {
+	return withRetryMode(request, retryOnRejection)
+}
+
+func withRetryMode(request *http.Request, mode retryMode) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), retryModeKey{}, mode))
+}
+
+func requestRetryMode(request *http.Request) retryMode {
+	if mode, ok := request.Context().Value(retryModeKey{}).(retryMode); ok {
+		return mode
+	}
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return retryAlways
+	}
+	return retryNever
+}
+
+// do sends request, retrying it as allowed by the client's RetryConfig. When
+// it gives up on a request that kept failing the last response, if any, is
+// returned together with a *RetryError.
+func (c *Client) do(request *http.Request) (*http.Response, error) {
+	if c.config.Retry.MaxElapsed <= 0 {
+		return c.retry(request)
+	}
+
+	// the deadline also cuts short attempts that hang, which would hold
+	// their request slot, and waits for a slot
+	ctx, cancel := context.WithTimeout(request.Context(), c.config.Retry.MaxElapsed)
+	response, err := c.retry(request.WithContext(ctx))
+	if response == nil {
+		cancel()
+		return nil, err
+	}
+	response.Body = &releasingBody{ReadCloser: response.Body, release: cancel}
+	return response, err
+}
+
+func (c *Client) retry(request *http.Request) (*http.Response, error) {
+	config := c.config.Retry
+	mode := requestRetryMode(request)
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+
+		if err := c.config.Auth.Authenticate(request); err != nil {
+			return nil, err
+		}
+		release, err := c.limiter.acquire(request.Context())
+		if err != nil {
+			return nil, err
+		}
+		response, err := c.httpClient.Do(request)
+		if err != nil {
+			release()
+		} else {
+			response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+		}
+
+		reason, retryable := retryReason(mode, response, err)
+		if !retryable || config.MaxRetries == 0 {
+			return response, err
+		}
+		if attempt >= config.MaxRetries {
+			return response, giveUp(attempt, reason, response, err)
+		}
+
+		delay := backoff(config, attempt)
+		if wait, ok := serverDelay(response); ok {
+			delay = wait
+		}
+		if config.MaxElapsed > 0 && time.Since(start)+delay > config.MaxElapsed {
+			return response, giveUp(attempt, reason+", next retry would exceed the time budget", response, err)
+		}
+
+		if response != nil {
+			response.Body.Close()
+		}
+		if err := sleep(request.Context(), delay); err != nil {
+			return nil, giveUp(attempt, reason, nil, err)
+		}
+	}
+}
+
+// retryReason tells whether a response or transport error is worth another
+// attempt for a request in the given mode, and why.
+func retryReason(mode retryMode, response *http.Response, err error) (string, bool) {
+	if mode == retryNever {
+		return "", false
+	}
+	if err != nil {
+		return err.Error(), mode == retryAlways
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return response.Status, true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return response.Status, mode == retryAlways
+	}
+	return "", false
+}
+
+func giveUp(retries int, reason string, response *http.Response, err error) error {
+	if err == nil {
+		err = fmt.Errorf("statusCode=%d", response.StatusCode)
+	}
+	return &RetryError{Retries: retries, Reason: reason, Err: err}
+}
+
+// backoff returns a random delay between half and all of the exponential
+// backoff for the given attempt, so that concurrent commands spread out.
+func backoff(config RetryConfig, attempt int) time.Duration {
+	delay := config.BaseDelay << uint(attempt)
+	if delay <= 0 || (config.MaxDelay > 0 && delay > config.MaxDelay) {
+		delay = config.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// serverDelay reads how long Jira asked us to back off, from either the
+// Retry-After header or, on Jira Cloud, the X-RateLimit-Reset header of an
+// exhausted rate limit.
+func serverDelay(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+
+	if value := response.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return nonNegative(time.Until(at)), true
+		}
+	}
+
+	if response.Header.Get("X-RateLimit-Remaining") == "0" {
+		if at, err := time.Parse(time.RFC3339, response.Header.Get("X-RateLimit-Reset")); err == nil {
+			return nonNegative(time.Until(at)), true
+		}
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
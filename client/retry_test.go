@@ -0,0 +1,170 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRetryConfig = RetryConfig{
+	MaxRetries: 2,
+	BaseDelay:  time.Millisecond,
+	MaxDelay:   5 * time.Millisecond,
+	MaxElapsed: time.Second,
+}
+
+// failingServer answers the first failures requests with status and the
+// rest with 200/201 and an empty JSON object.
+func failingServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	server, calls := failingServer(t, 2, http.StatusServiceUnavailable, nil)
+	c := newTestClient(t, Config{Host: server.URL, Retry: testRetryConfig})
+
+	_, err := c.GetIssueInfo("TEST-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRetryMarkedPostOnRejection(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}})
+	c := newTestClient(t, Config{Host: server.URL, Retry: testRetryConfig})
+
+	_, err := c.CommentIssue("TEST-1", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestNoRetryOfNonIdempotentPostOnGatewayError(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusBadGateway, nil)
+	c := newTestClient(t, Config{Host: server.URL, Retry: testRetryConfig})
+
+	_, err := c.CommentIssue("TEST-1", "hello")
+	assert.EqualError(t, err, "issueId=TEST-1 : statusCode=502")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestNoRetryWhenDisabled(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusServiceUnavailable, nil)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	_, err := c.GetIssueInfo("TEST-1")
+	assert.EqualError(t, err, "issueId=TEST-1 : statusCode=503")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := failingServer(t, 10, http.StatusServiceUnavailable, nil)
+	c := newTestClient(t, Config{Host: server.URL, Retry: testRetryConfig})
+
+	_, err := c.GetIssueInfo("TEST-1")
+
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 2, retryErr.Retries)
+	assert.Equal(t, "503 Service Unavailable", retryErr.Reason)
+	assert.EqualError(t, err, "issueId=TEST-1 : statusCode=503 (gave up after 2 retries: 503 Service Unavailable)")
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRetryRespectsTimeBudget(t *testing.T) {
+	server, calls := failingServer(t, 10, http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}})
+	c := newTestClient(t, Config{Host: server.URL, Retry: testRetryConfig})
+
+	start := time.Now()
+	_, err := c.SearchIssues("project = TEST", 0, 10)
+
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 0, retryErr.Retries)
+	assert.Equal(t, "429 Too Many Requests, next retry would exceed the time budget", retryErr.Reason)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestServerDelay(t *testing.T) {
+	reset := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		ok       bool
+	}{
+		{"retry after seconds", http.Header{"Retry-After": {"7"}}, 7 * time.Second, true},
+		{"rate limit reset", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}}, time.Minute, true},
+		{"rate limit not exhausted", http.Header{"X-Ratelimit-Remaining": {"3"}, "X-Ratelimit-Reset": {reset}}, 0, false},
+		{"no headers", http.Header{}, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, ok := serverDelay(&http.Response{Header: tc.header})
+			assert.Equal(t, tc.ok, ok)
+			assert.InDelta(t, float64(tc.expected), float64(delay), float64(2*time.Second))
+		})
+	}
+}
+
+func TestRetryTimeBudgetCutsShortHangingRequests(t *testing.T) {
+	var calls int32
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(hang) })
+	retry := testRetryConfig
+	retry.MaxElapsed = 100 * time.Millisecond
+	c := newTestClient(t, Config{Host: server.URL, Retry: retry, RateLimit: RateLimitConfig{MaxInFlight: 1}})
+
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		_, err := c.GetIssueInfo("TEST-1")
+
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+		assert.True(t, time.Since(start) < time.Second)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "the request slot is released")
+}
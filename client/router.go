@@ -0,0 +1,104 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownInstance is returned by Router.Route for instance names that are
+// not configured.
+var ErrUnknownInstance = errors.New("unknown jira instance")
+
+type (
+	// Instance is a named Jira instance together with the project keys it owns.
+	Instance struct {
+		Name     string
+		Client   *Client
+		Projects []string
+	}
+
+	// Router picks the Client a command runs against when the pack is
+	// configured with more than one Jira instance.
+	Router struct {
+		instances       map[string]*Client
+		projects        map[string]string
+		defaultInstance string
+	}
+)
+
+// NewRouter returns a Router over instances. Commands that neither name an
+// instance nor reference a project owned by one are sent to defaultInstance.
+func NewRouter(defaultInstance string, instances ...Instance) (*Router, error) {
+	r := &Router{
+		instances:       map[string]*Client{},
+		projects:        map[string]string{},
+		defaultInstance: strings.ToLower(defaultInstance),
+	}
+
+	for _, instance := range instances {
+		name := strings.ToLower(instance.Name)
+		if _, ok := r.instances[name]; ok {
+			return nil, fmt.Errorf("jira instance %q is configured more than once", instance.Name)
+		}
+		r.instances[name] = instance.Client
+
+		for _, project := range instance.Projects {
+			project = strings.ToUpper(strings.TrimSpace(project))
+			if owner, ok := r.projects[project]; ok {
+				return nil, fmt.Errorf("project %s is assigned to both %q and %q jira instances", project, owner, name)
+			}
+			r.projects[project] = name
+		}
+	}
+
+	if _, ok := r.instances[r.defaultInstance]; !ok {
+		return nil, fmt.Errorf("default jira instance %q is not configured", defaultInstance)
+	}
+	return r, nil
+}
+
+// Route returns the client for the named instance. When instance is empty the
+// client is chosen by the project of key, which may be a project key ("ABC")
+// or an issue key ("ABC-123"), falling back to the default instance.
+func (r *Router) Route(instance, key string) (*Client, error) {
+	if instance != "" {
+		c, ok := r.instances[strings.ToLower(instance)]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownInstance, instance)
+		}
+		return c, nil
+	}
+
+	if name, ok := r.projects[projectOf(key)]; ok {
+		return r.instances[name], nil
+	}
+
+	return r.instances[r.defaultInstance], nil
+}
+
+// projectOf returns the project key of an issue key, e.g. "ABC" for "ABC-123".
+// Project keys are returned as they are.
+func projectOf(key string) string {
+	project := strings.ToUpper(strings.TrimSpace(key))
+	if i := strings.Index(project, "-"); i >= 0 {
+		project = project[:i]
+	}
+	return project
+}
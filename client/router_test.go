@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRouteSelectsInstance(t *testing.T) {
+	onprem := newTestClient(t, Config{Host: "https://jira.onprem"})
+	cloud := newTestClient(t, Config{Host: "https://example.atlassian.net"})
+	r, err := NewRouter("onprem",
+		Instance{Name: "onprem", Client: onprem, Projects: []string{"OPS"}},
+		Instance{Name: "cloud", Client: cloud, Projects: []string{"web", " APP "}},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		instance string
+		key      string
+		expected *Client
+	}{
+		{"explicit instance wins over project", "Cloud", "OPS-1", cloud},
+		{"issue key project", "", "WEB-12", cloud},
+		{"bare project key", "", "app", cloud},
+		{"unowned project falls back to default", "", "OTHER-1", onprem},
+		{"no key falls back to default", "", "", onprem},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := r.Route(tc.instance, tc.key)
+			require.NoError(t, err)
+			assert.Same(t, tc.expected, c)
+		})
+	}
+}
+
+func TestRouteUnknownInstance(t *testing.T) {
+	r, err := NewRouter("default", Instance{Name: "default", Client: newTestClient(t, Config{})})
+	require.NoError(t, err)
+
+	_, err = r.Route("cloud", "OPS-1")
+	assert.EqualError(t, err, `unknown jira instance "cloud"`)
+}
+
+func TestNewRouterRejectsInvalidConfig(t *testing.T) {
+	c := newTestClient(t, Config{})
+
+	_, err := NewRouter("missing", Instance{Name: "default", Client: c})
+	assert.EqualError(t, err, `default jira instance "missing" is not configured`)
+
+	_, err = NewRouter("a", Instance{Name: "a", Client: c}, Instance{Name: "A", Client: c})
+	assert.EqualError(t, err, `jira instance "A" is configured more than once`)
+
+	_, err = NewRouter("a",
+		Instance{Name: "a", Client: c, Projects: []string{"OPS"}},
+		Instance{Name: "b", Client: c, Projects: []string{"ops"}},
+	)
+	assert.EqualError(t, err, `project OPS is assigned to both "a" and "b" jira instances`)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSVerificationIsOnByDefault(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Host: server.URL})
+	_, err := c.GetTransitions("TEST-1")
+	assert.Error(t, err)
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Host: server.URL, TLS: TLSConfig{InsecureSkipVerify: true}})
+	_, err := c.GetTransitions("TEST-1")
+	assert.NoError(t, err)
+}
+
+func TestTLSCustomCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	dir := tempDir(t)
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	c := newTestClient(t, Config{Host: server.URL, TLS: TLSConfig{CAFile: caFile}})
+	_, err := c.GetTransitions("TEST-1")
+	assert.NoError(t, err)
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	dir := tempDir(t)
+	clientCert, certFile, keyFile := createClientCertificate(t, dir)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(okHandler))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	withoutCert := newTestClient(t, Config{Host: server.URL, TLS: TLSConfig{CAFile: caFile}})
+	_, err := withoutCert.GetTransitions("TEST-1")
+	assert.Error(t, err)
+
+	withCert := newTestClient(t, Config{Host: server.URL, TLS: TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}})
+	_, err = withCert.GetTransitions("TEST-1")
+	assert.NoError(t, err)
+}
+
+func TestTLSInvalidConfig(t *testing.T) {
+	dir := tempDir(t)
+	notPEM := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600))
+
+	_, err := New(Config{TLS: TLSConfig{CAFile: notPEM}})
+	assert.EqualError(t, err, "no certificates found in CA file "+notPEM)
+
+	_, err = New(Config{TLS: TLSConfig{CertFile: "client.pem"}})
+	assert.EqualError(t, err, "client certificate and key files must be set together")
+}
+
+func okHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte(`{"transitions":[]}`))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "flyte-jira-tls")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	file := filepath.Join(dir, name)
+	b := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, ioutil.WriteFile(file, b, 0600))
+	return file
+}
+
+// createClientCertificate writes a self-signed client certificate and its
+// key to dir.
+func createClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "flyte-jira"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return cert, writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+}
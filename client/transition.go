@@ -2,7 +2,6 @@ package client
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 )
@@ -15,7 +14,7 @@ type transitionRequest struct {
 	Transition transition `json:"transition"`
 }
 
-func Transition(issueId, transitionId string) (string, error) {
+func (c *Client) Transition(issueId, transitionId string) (string, error) {
 	path := fmt.Sprintf("/rest/api/2/issue/%s/transitions", issueId)
 	r := transitionRequest{
 		Transition: transition{Id: transitionId},
@@ -24,27 +23,12 @@ func Transition(issueId, transitionId string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	req, err := constructPostRequest(path, string(b))
+	req, err := c.newRequest(http.MethodPost, path, b)
 	if err != nil {
 		return "", err
 	}
-	resp, err := SendRequestWithoutResp(req)
-	if err != nil {
-		return req.URL.Path, err
-	}
-
-	switch resp {
-	case http.StatusNoContent:
-		err = nil
-	case http.StatusBadRequest:
-		err = errors.New("no transition specified")
-	case http.StatusUnauthorized:
-		err = errors.New("invalid permission to transition an issue")
-	case http.StatusNotFound:
-		err = errors.New("issue or user does not exist")
-	default:
-		err = fmt.Errorf("unsupported status code %d", resp)
+	if err := c.sendRequestWithoutResp(markRetryable(req)); err != nil {
+		return req.URL.Path, fmt.Errorf("issueId=%s transitionId=%s : %w", issueId, transitionId, err)
 	}
-
-	return req.URL.Path, err
+	return req.URL.Path, nil
 }
@@ -0,0 +1,85 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// IssueUpdate is the body of an issue edit. Fields replaces the value
+	// of the given fields, e.g. "summary": "New summary", while Update
+	// applies operations to them, e.g. "labels": [{"add": "triaged"}].
+	IssueUpdate struct {
+		Fields map[string]interface{}      `json:"fields,omitempty"`
+		Update map[string][]FieldOperation `json:"update,omitempty"`
+		// CustomFields sets fields by name, see resolveCustomFields.
+		CustomFields map[string]interface{} `json:"customFields,omitempty"`
+	}
+
+	// FieldOperation is a single edit operation on a field, keyed by its
+	// verb: "set", "add", "remove" or "edit".
+	FieldOperation map[string]interface{}
+)
+
+// UpdateIssue edits the fields of an existing issue.
+func (c *Client) UpdateIssue(issueId string, update IssueUpdate) error {
+	custom, err := c.resolveCustomFields(update.CustomFields)
+	if err != nil {
+		return fmt.Errorf("issueId=%s : %w", issueId, err)
+	}
+	fields := map[string]interface{}{}
+	for id, value := range update.Fields {
+		field, err := c.updateField(id)
+		if err != nil {
+			return fmt.Errorf("issueId=%s : %w", issueId, err)
+		}
+		if fields[id], err = c.cloudFieldValue(field, value); err != nil {
+			return fmt.Errorf("issueId=%s : %w", issueId, err)
+		}
+	}
+	for id, value := range custom {
+		fields[id] = value
+	}
+	operations, err := c.cloudOperations(update.Update)
+	if err != nil {
+		return fmt.Errorf("issueId=%s : %w", issueId, err)
+	}
+
+	b, err := json.Marshal(IssueUpdate{Fields: fields, Update: operations})
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/rest/api/2/issue/%s", issueId)
+	request, err := c.newRequest(http.MethodPut, path, b)
+	if err != nil {
+		return err
+	}
+	// setting fields is idempotent, but "add" operations may not be, e.g.
+	// adding a comment or a worklog
+	if len(update.Update) > 0 {
+		request = markRetryable(request)
+	}
+
+	if err := c.sendRequestWithoutResp(request); err != nil {
+		return fmt.Errorf("issueId=%s : %w", issueId, err)
+	}
+	return nil
+}
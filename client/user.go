@@ -0,0 +1,416 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUserNotFound is returned when no single Jira user matches a user name,
+// an email address or another identity of a user.
+var ErrUserNotFound = errors.New("user not found")
+
+const (
+	// userCacheSize is how many resolved users are kept.
+	userCacheSize = 1000
+	// userCacheTTL is how long a resolved user is used before it is looked
+	// up again, e.g. in case it was deactivated.
+	userCacheTTL = time.Hour
+)
+
+var (
+	// mentionPattern matches @username and @email tokens that are not part
+	// of a word, an email address or an existing [~mention]. The second
+	// group is the user name or email address.
+	mentionPattern = regexp.MustCompile(`(^|[^\w@.~\[])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+	// slackUserPattern matches the user mentions and email links of Slack
+	// messages, e.g. <@U024BE7LH|jdoe> or <mailto:jdoe@example.com|jdoe@example.com>.
+	// The last group is the label, or else the target of the link.
+	slackUserPattern = regexp.MustCompile(`^<(?:@|mailto:)([^|>]+)(?:\|([^>]*))?>$`)
+	// wikiCodePattern matches the {code} and {noformat} blocks and the
+	// {{monospaced}} spans of wiki markup, where @ is no mention.
+	wikiCodePattern = regexp.MustCompile(`(?s)\{code(?::[^}]*)?\}.*?\{code\}|\{noformat(?::[^}]*)?\}.*?\{noformat\}|\{\{.*?\}\}`)
+)
+
+type (
+	// userCache holds the latest resolved users, keyed by the identity
+	// they were resolved from.
+	userCache struct {
+		mu      sync.Mutex
+		entries map[string]*list.Element
+		// order lists the entries, the most recently used first
+		order *list.List
+	}
+
+	userCacheEntry struct {
+		key    string
+		user   domain.User
+		loaded time.Time
+	}
+)
+
+// FindUser returns the user identity stands for: an account id (on Jira
+// Cloud), a user name, an email address, a display name or a Slack handle
+// such as @jdoe, which is matched to the user name or to the email address
+// before the @. With issueKey set, only users who can be assigned the issue
+// are considered. Resolved users are cached.
+func (c *Client) FindUser(identity, issueKey string) (domain.User, error) {
+	query := userQuery(identity)
+	if query == "" {
+		return domain.User{}, fmt.Errorf("%w: %q is not a user", ErrUserNotFound, identity)
+	}
+	key := strings.ToUpper(issueKey) + "/" + strings.ToLower(query)
+	if user, ok := c.users.get(key); ok {
+		return user, nil
+	}
+
+	var user domain.User
+	var err error
+	if c.isCloud() && accountIDPattern.MatchString(query) {
+		user, err = c.getUser(query)
+	} else {
+		user, err = c.searchUser(query, issueKey)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	c.users.put(key, user)
+	return user, nil
+}
+
+// userRef refers to user, whom FindUser resolves unless it is an account id
+// on Jira Cloud or looks like a user name on Jira Server/Data Center, i.e. is
+// neither an email address, a display name nor a Slack handle. With issueKey
+// set, the user must be assignable to the issue.
+func (c *Client) userRef(user, issueKey string) (User, error) {
+	user = strings.TrimSpace(user)
+	cloud := c.isCloud()
+	switch {
+	// -1 stands for the default assignee
+	case user == "" || user == "-1" || cloud && accountIDPattern.MatchString(user):
+		if cloud {
+			return User{AccountID: user}, nil
+		}
+		return User{Name: user}, nil
+	case !cloud && !strings.ContainsAny(user, "@< "):
+		return User{Name: user}, nil
+	}
+
+	found, err := c.FindUser(user, issueKey)
+	if err != nil {
+		return User{}, err
+	}
+	if cloud {
+		return User{AccountID: found.AccountID}, nil
+	}
+	return User{Name: found.Name}, nil
+}
+
+// userQuery returns what to search the user identity stands for by: the
+// label of Slack mentions, or else their target, and handles without their @.
+func userQuery(identity string) string {
+	query := strings.TrimSpace(identity)
+	if match := slackUserPattern.FindStringSubmatch(query); match != nil {
+		query = match[1]
+		if match[2] != "" {
+			query = match[2]
+		}
+	}
+	return strings.TrimSpace(strings.TrimPrefix(query, "@"))
+}
+
+// getUser returns the Jira Cloud user with the given account id.
+func (c *Client) getUser(accountID string) (domain.User, error) {
+	var user domain.User
+	request, err := c.newRequest(http.MethodGet, "/rest/api/2/user?accountId="+url.QueryEscape(accountID), nil)
+	if err != nil {
+		return user, err
+	}
+	if err := c.sendRequest(request, &user); err != nil {
+		var jiraErr *JiraError
+		if errors.As(err, &jiraErr) && jiraErr.StatusCode == http.StatusNotFound {
+			return user, fmt.Errorf("%w: no user has the account id %q", ErrUserNotFound, accountID)
+		}
+		return user, fmt.Errorf("user=%s : %w", accountID, err)
+	}
+	return user, nil
+}
+
+// searchUser returns the single user matching query, see matchUser, among
+// the users who can be assigned issueKey when it is set.
+func (c *Client) searchUser(query, issueKey string) (domain.User, error) {
+	param := "username"
+	if c.isCloud() {
+		param = "query"
+	}
+	path := fmt.Sprintf("/rest/api/2/user/search?%s=%s", param, url.QueryEscape(query))
+	if issueKey != "" {
+		path = fmt.Sprintf("/rest/api/2/user/assignable/search?issueKey=%s&%s=%s", url.QueryEscape(issueKey), param, url.QueryEscape(query))
+	}
+	request, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return domain.User{}, err
+	}
+	var users []domain.User
+	if err := c.sendRequest(request, &users); err != nil {
+		var jiraErr *JiraError
+		if issueKey != "" && errors.As(err, &jiraErr) && jiraErr.StatusCode == http.StatusNotFound {
+			return domain.User{}, fmt.Errorf("user=%s : issue %s does not exist or you cannot see it : %w", query, issueKey, err)
+		}
+		return domain.User{}, fmt.Errorf("user=%s : %w", query, err)
+	}
+
+	user, err := matchUser(users, query)
+	if err != nil && issueKey != "" {
+		return domain.User{}, fmt.Errorf("%w among the users who can be assigned %s", err, issueKey)
+	}
+	return user, err
+}
+
+// matchUser returns the user of users whose account id, name, key or email
+// address is query, or else whose display name or email address before the @
+// is. The search being fuzzy, a single user is only taken as is for email
+// addresses, which Jira Cloud may hide.
+func matchUser(users []domain.User, query string) (domain.User, error) {
+	for _, user := range users {
+		if user.AccountID == query || strings.EqualFold(user.Name, query) || strings.EqualFold(user.Key, query) || strings.EqualFold(user.EmailAddress, query) {
+			return user, nil
+		}
+	}
+	var matches []domain.User
+	for _, user := range users {
+		localPart := strings.SplitN(user.EmailAddress, "@", 2)[0]
+		if strings.EqualFold(user.DisplayName, query) || localPart != "" && strings.EqualFold(localPart, query) {
+			matches = append(matches, user)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		return domain.User{}, fmt.Errorf("%w: %d users match %q", ErrUserNotFound, len(matches), query)
+	case len(users) == 1 && strings.Contains(query, "@"):
+		return users[0], nil
+	case len(users) > 1:
+		return domain.User{}, fmt.Errorf("%w: %d users match %q", ErrUserNotFound, len(users), query)
+	}
+	return domain.User{}, fmt.Errorf("%w: no user matches %q", ErrUserNotFound, query)
+}
+
+func (u *userCache) get(key string) (domain.User, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	element, ok := u.entries[key]
+	if !ok {
+		return domain.User{}, false
+	}
+	entry := element.Value.(*userCacheEntry)
+	if time.Since(entry.loaded) > userCacheTTL {
+		u.order.Remove(element)
+		delete(u.entries, key)
+		return domain.User{}, false
+	}
+	u.order.MoveToFront(element)
+	return entry.user, true
+}
+
+func (u *userCache) put(key string, user domain.User) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.entries == nil {
+		u.entries = map[string]*list.Element{}
+		u.order = list.New()
+	}
+	if element, ok := u.entries[key]; ok {
+		element.Value = &userCacheEntry{key: key, user: user, loaded: time.Now()}
+		u.order.MoveToFront(element)
+		return
+	}
+	u.entries[key] = u.order.PushFront(&userCacheEntry{key: key, user: user, loaded: time.Now()})
+	if u.order.Len() > userCacheSize {
+		oldest := u.order.Back()
+		u.order.Remove(oldest)
+		delete(u.entries, oldest.Value.(*userCacheEntry).key)
+	}
+}
+
+// mention returns the wiki markup mentioning user.
+func mention(user domain.User) string {
+	if user.AccountID != "" {
+		return "[~accountid:" + user.AccountID + "]"
+	}
+	return "[~" + user.Name + "]"
+}
+
+// resolveMentions replaces the @username and @email tokens of body, wiki
+// markup or a document, with mentions of the users they name, failing if any
+// of them is not found.
+func (c *Client) resolveMentions(body interface{}) (interface{}, error) {
+	switch body := body.(type) {
+	case string:
+		var texts []string
+		replaceOutsideCode(body, func(text string) string {
+			texts = append(texts, text)
+			return text
+		})
+		mentions, err := c.lookupMentions(texts...)
+		if err != nil {
+			return nil, err
+		}
+		return replaceOutsideCode(body, func(text string) string {
+			return mentionPattern.ReplaceAllStringFunc(text, func(token string) string {
+				match := mentionPattern.FindStringSubmatch(token)
+				query := strings.TrimRight(match[2], ".")
+				user, ok := mentions[query]
+				if !ok {
+					return token
+				}
+				return match[1] + mention(user) + strings.TrimPrefix(match[2], query)
+			})
+		}), nil
+	case *markup.Node:
+		mentions, err := c.lookupMentions(adfTexts(*body)...)
+		if err != nil {
+			return nil, err
+		}
+		mentionADF(body, mentions)
+		return body, nil
+	}
+	return body, nil
+}
+
+// replaceOutsideCode replaces the parts of the wiki markup text outside of
+// code, see wikiCodePattern, with what replace returns for them.
+func replaceOutsideCode(text string, replace func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, code := range wikiCodePattern.FindAllStringIndex(text, -1) {
+		b.WriteString(replace(text[last:code[0]]))
+		b.WriteString(text[code[0]:code[1]])
+		last = code[1]
+	}
+	b.WriteString(replace(text[last:]))
+	return b.String()
+}
+
+// lookupMentions returns the users named by the @username and @email tokens
+// of texts, keyed by user name or email address.
+func (c *Client) lookupMentions(texts ...string) (map[string]domain.User, error) {
+	mentions := map[string]domain.User{}
+	var failures []string
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+			query := strings.TrimRight(match[2], ".")
+			if seen[query] || query == "" {
+				continue
+			}
+			seen[query] = true
+			user, err := c.FindUser(query, "")
+			if errors.Is(err, ErrUserNotFound) {
+				failures = append(failures, "@"+query)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			mentions[query] = user
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("%w: no single user matches %s", ErrUserNotFound, strings.Join(failures, ", "))
+	}
+	return mentions, nil
+}
+
+// adfTexts returns the texts of the text nodes below node that may hold
+// mentions, which code may not.
+func adfTexts(node markup.Node) []string {
+	if node.Type == "codeBlock" {
+		return nil
+	}
+	if node.Type == "text" && !hasCodeMark(node) {
+		return []string{node.Text}
+	}
+	var texts []string
+	for _, child := range node.Content {
+		texts = append(texts, adfTexts(child)...)
+	}
+	return texts
+}
+
+// mentionADF replaces the @username and @email tokens of the text nodes
+// below node with mention nodes of the users in mentions.
+func mentionADF(node *markup.Node, mentions map[string]domain.User) {
+	if node.Type == "codeBlock" {
+		return
+	}
+	content := make([]markup.Node, 0, len(node.Content))
+	for _, child := range node.Content {
+		if child.Type != "text" || hasCodeMark(child) {
+			mentionADF(&child, mentions)
+			content = append(content, child)
+			continue
+		}
+		start := 0
+		for _, match := range mentionPattern.FindAllStringSubmatchIndex(child.Text, -1) {
+			// match[3] is the @ before the user name or email address
+			query := strings.TrimRight(child.Text[match[4]:match[5]], ".")
+			user, ok := mentions[query]
+			if !ok {
+				continue
+			}
+			if match[3] > start {
+				content = append(content, markup.Node{Type: "text", Text: child.Text[start:match[3]], Marks: child.Marks})
+			}
+			name := user.DisplayName
+			if name == "" {
+				name = query
+			}
+			content = append(content, markup.Node{
+				Type:  "mention",
+				Attrs: map[string]interface{}{"id": user.AccountID, "text": "@" + name},
+			})
+			start = match[4] + len(query)
+		}
+		if start < len(child.Text) {
+			content = append(content, markup.Node{Type: "text", Text: child.Text[start:], Marks: child.Marks})
+		}
+	}
+	node.Content = content
+}
+
+func hasCodeMark(node markup.Node) bool {
+	for _, mark := range node.Marks {
+		if mark.Type == "code" {
+			return true
+		}
+	}
+	return false
+}
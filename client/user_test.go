@@ -0,0 +1,346 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var testUsers = []domain.User{
+	{Name: "jdoe", Key: "jdoe", EmailAddress: "john.doe@example.com", DisplayName: "John Doe"},
+	{Name: "jdoe2", Key: "JIRAUSER10100", EmailAddress: "jane.doe@example.com", DisplayName: "Jane Doe"},
+	{AccountID: "5b10a2844c20165700ede21g", EmailAddress: "ops@example.com", DisplayName: "Ops"},
+}
+
+// userServer answers user searches with the testUsers whose name, email or
+// display name starts with the searched user name, or query on Jira Cloud,
+// which is reached through the REST API v3 when host is under atlassian.net.
+func userServer(t *testing.T, host string) (*Client, *[]string) {
+	var searches []string
+	searchPath := "/rest/api/2/user/search"
+	if host != "" {
+		searchPath = "/rest/api/3/user/search"
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != searchPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		searches = append(searches, r.URL.RawQuery)
+		query := r.URL.Query().Get("username") + r.URL.Query().Get("query")
+		users := []domain.User{}
+		for _, user := range testUsers {
+			if strings.HasPrefix(user.Name, query) || strings.HasPrefix(user.EmailAddress, query) || strings.HasPrefix(user.DisplayName, query) {
+				users = append(users, user)
+			}
+		}
+		json.NewEncoder(w).Encode(users)
+	}))
+	t.Cleanup(server.Close)
+	if host == "" {
+		return newTestClient(t, Config{Host: server.URL}), &searches
+	}
+
+	// send the requests to host to the server
+	serverURL, _ := url.Parse(server.URL)
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme, r.URL.Host = serverURL.Scheme, serverURL.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	return newTestClient(t, Config{Host: host}, WithHTTPClient(&http.Client{Transport: transport})), &searches
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestResolveMentionsOnServer(t *testing.T) {
+	c, searches := userServer(t, "")
+
+	text, err := c.resolveMentions("@jdoe please check with @jane.doe@example.com. Thanks @jdoe, ops@example.com and [~jdoe2]")
+
+	require.NoError(t, err)
+	assert.Equal(t, "[~jdoe] please check with [~jdoe2]. Thanks [~jdoe], ops@example.com and [~jdoe2]", text)
+	assert.Equal(t, []string{"username=jdoe", "username=jane.doe%40example.com"}, *searches)
+}
+
+func TestResolveMentionsOnCloud(t *testing.T) {
+	c, searches := userServer(t, "https://example.atlassian.net")
+
+	text, err := c.resolveMentions("paging @ops@example.com")
+
+	require.NoError(t, err)
+	assert.Equal(t, "paging [~accountid:5b10a2844c20165700ede21g]", text)
+	assert.Equal(t, []string{"query=ops%40example.com"}, *searches)
+}
+
+func TestResolveMentionsSkipsCode(t *testing.T) {
+	c, searches := userServer(t, "")
+
+	text, err := c.resolveMentions("{code:java}@Override{code} {noformat}@Test{noformat} {{@Nullable}} @jdoe")
+
+	require.NoError(t, err)
+	assert.Equal(t, "{code:java}@Override{code} {noformat}@Test{noformat} {{@Nullable}} [~jdoe]", text)
+	assert.Equal(t, []string{"username=jdoe"}, *searches)
+}
+
+func TestResolveMentionsInDocument(t *testing.T) {
+	c, _ := userServer(t, "https://example.atlassian.net")
+	doc := markup.ToADF("paging **@ops@example.com**, not `@jdoe`", markup.Markdown)
+
+	resolved, err := c.resolveMentions(doc)
+
+	require.NoError(t, err)
+	actual, err := json.Marshal(resolved.(*markup.Node).Content)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"type":"paragraph","content":[
+		{"type":"text","text":"paging "},
+		{"type":"mention","attrs":{"id":"5b10a2844c20165700ede21g","text":"@Ops"}},
+		{"type":"text","text":", not "},
+		{"type":"text","text":"@jdoe","marks":[{"type":"code"}]}]}]`, string(actual))
+}
+
+func TestResolveMentionsOfUnknownUsers(t *testing.T) {
+	c, _ := userServer(t, "")
+
+	_, err := c.resolveMentions("@jdoe @nobody and @j")
+
+	assert.True(t, errors.Is(err, ErrUserNotFound))
+	assert.EqualError(t, err, "user not found: no single user matches @nobody, @j")
+}
+
+func TestAddCommentWithVisibilityAndMentions(t *testing.T) {
+	var posted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/user/search":
+			json.NewEncoder(w).Encode(testUsers[:1])
+		case "/rest/api/2/issue/TEST-1/comment":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "10001"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	comment, err := c.AddComment("TEST-1", NewComment{
+		Body:            "@jdoe the database password was rotated",
+		Visibility:      &domain.Visibility{Type: "role", Value: "Developers"},
+		ResolveMentions: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "10001", comment.ID)
+	assert.Equal(t, map[string]interface{}{
+		"body":       "[~jdoe] the database password was rotated",
+		"visibility": map[string]interface{}{"type": "role", "value": "Developers"},
+	}, posted)
+}
+
+func TestFindUser(t *testing.T) {
+	for identity, expected := range map[string]string{
+		"jdoe":                                   "jdoe",
+		"@jdoe2":                                 "jdoe2",
+		"jane.doe@example.com":                   "jdoe2",
+		"@jane.doe":                              "jdoe2",
+		"Jane Doe":                               "jdoe2",
+		"<@U024BE7LH|john.doe>":                  "jdoe",
+		"<mailto:jane.doe@example.com|jane.doe>": "jdoe2",
+	} {
+		t.Run(identity, func(t *testing.T) {
+			c, _ := userServer(t, "")
+
+			user, err := c.FindUser(identity, "")
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, user.Name)
+		})
+	}
+}
+
+func TestFindUserFailures(t *testing.T) {
+	c, _ := userServer(t, "")
+
+	for identity, message := range map[string]string{
+		"j":            `user not found: 2 users match "j"`,
+		"nobody":       `user not found: no user matches "nobody"`,
+		"<@U024BE7LH>": `user not found: no user matches "U024BE7LH"`,
+		" @ ":          `user not found: " @ " is not a user`,
+	} {
+		_, err := c.FindUser(identity, "")
+
+		assert.True(t, errors.Is(err, ErrUserNotFound), "%v", err)
+		assert.EqualError(t, err, message)
+	}
+}
+
+func TestFindUserIsCached(t *testing.T) {
+	c, searches := userServer(t, "")
+
+	for _, identity := range []string{"jane.doe@example.com", "Jane.Doe@example.com", "jane.doe@example.com"} {
+		_, err := c.FindUser(identity, "")
+		require.NoError(t, err)
+	}
+	_, err := c.FindUser("jane.doe@example.com", "FLYTE-1")
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"username=jane.doe%40example.com"}, *searches)
+}
+
+func TestFindUserByAccountID(t *testing.T) {
+	c, requests := cloudServer(t, map[string]string{
+		"/rest/api/3/user": `{"accountId": "5b10a2844c20165700ede21f", "displayName": "Jane Doe"}`,
+	})
+
+	user, err := c.FindUser("5b10a2844c20165700ede21f", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", user.DisplayName)
+	assert.Equal(t, "/rest/api/3/user", (*requests)[0].Path)
+}
+
+func TestFindAssignableUser(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/user/assignable/search" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+		query = r.URL.Query()
+		if query.Get("issueKey") == "NOPE-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(testUsers[:1])
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	user, err := c.FindUser("john.doe", "FLYTE-1")
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe", user.Name)
+	assert.Equal(t, url.Values{"issueKey": {"FLYTE-1"}, "username": {"john.doe"}}, query)
+
+	_, err = c.FindUser("jane.doe", "FLYTE-1")
+	assert.EqualError(t, err, `user not found: no user matches "jane.doe" among the users who can be assigned FLYTE-1`)
+
+	_, err = c.FindUser("john.doe", "NOPE-1")
+	assert.EqualError(t, err, "user=john.doe : issue NOPE-1 does not exist or you cannot see it : statusCode=404")
+}
+
+func TestUserCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	var cache userCache
+	for i := 0; i < userCacheSize; i++ {
+		cache.put(strconv.Itoa(i), domain.User{Name: strconv.Itoa(i)})
+	}
+	_, ok := cache.get("0")
+	require.True(t, ok)
+
+	cache.put("new", domain.User{Name: "new"})
+
+	_, ok = cache.get("0")
+	assert.True(t, ok)
+	_, ok = cache.get("1")
+	assert.False(t, ok)
+	_, ok = cache.get("new")
+	assert.True(t, ok)
+	assert.Equal(t, userCacheSize, cache.order.Len())
+}
+
+func TestAssignIssueByEmail(t *testing.T) {
+	var assigned map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/user/assignable/search":
+			json.NewEncoder(w).Encode(testUsers[1:2])
+		case "/rest/api/2/issue/FLYTE-1/assignee":
+			json.NewDecoder(r.Body).Decode(&assigned)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	require.NoError(t, c.AssignIssue("FLYTE-1", "jane.doe@example.com"))
+	assert.Equal(t, map[string]interface{}{"name": "jdoe2"}, assigned)
+}
+
+func TestAssignIssueNotFound(t *testing.T) {
+	for issue, message := range map[string]string{
+		"FLYTE-1": "issueId=FLYTE-1 user=nobody : user not found: the user does not exist or cannot be assigned the issue",
+		"NOPE-1":  "issueId=NOPE-1 user=nobody : issue NOPE-1 does not exist or you cannot see it : statusCode=404",
+	} {
+		t.Run(issue, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/FLYTE-1" {
+					w.Write([]byte(`{"key": "FLYTE-1"}`))
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			t.Cleanup(server.Close)
+			c := newTestClient(t, Config{Host: server.URL})
+
+			err := c.AssignIssue(issue, "nobody")
+
+			assert.EqualError(t, err, message)
+		})
+	}
+}
+
+func TestCreateIssueWithReporterEmail(t *testing.T) {
+	var fields map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/user/search":
+			json.NewEncoder(w).Encode(testUsers[1:2])
+		case "/rest/api/2/issue/":
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			fields = body.Fields
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, Config{Host: server.URL})
+
+	_, err := c.CreateIssue(NewIssue{Project: "FLYTE", IssueType: "Bug", Summary: "summary", Reporter: "jane.doe@example.com", Assignee: "jdoe"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "jdoe2"}, fields["reporter"])
+	assert.Equal(t, map[string]interface{}{"name": "jdoe"}, fields["assignee"])
+}
@@ -8,12 +8,6 @@ import (
 )
 
 var (
-	IssueAssignCommand = flyte.Command{
-		Name:         "IssueAssign",
-		OutputEvents: []flyte.EventDef{assignEventDef, assignFailureEventDef},
-		Handler:      assignIssueHandler,
-	}
-
 	assignEventDef = flyte.EventDef{
 		Name: "Assign",
 	}
@@ -23,41 +17,43 @@ var (
 	}
 )
 
-type (
-	assignFailurePayload struct {
-		assignRequest
-		Error string `json:"error"`
+func IssueAssignCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "IssueAssign",
+		OutputEvents: []flyte.EventDef{assignEventDef, assignFailureEventDef},
+		Handler:      assignIssueHandler(r),
 	}
+}
 
+type (
 	assignRequest struct {
 		IssueId  string `json:"issueId"`
 		Username string `json:"username,omitempty"`
+		instanceSelector
 	}
 )
 
-func assignIssueHandler(input json.RawMessage) flyte.Event {
-	req := assignRequest{}
-	if err := json.Unmarshal(input, &req); err != nil {
-		log.Printf("Error unmarshaling Issue Assign Request [%s]: %s", input, err)
-		return newAssignFailureEvent(req, err)
-	}
-
-	if err := client.AssignIssue(req.IssueId, req.Username); err != nil {
-		log.Printf("Error assigning Issue %s to User %s: %s", req.IssueId, req.Username, err)
-		return newAssignFailureEvent(req, err)
-	}
-	return flyte.Event{
-		EventDef: assignEventDef,
-		Payload:  req,
-	}
-}
-
-func newAssignFailureEvent(request assignRequest, err error) flyte.Event {
-	return flyte.Event{
-		EventDef: assignFailureEventDef,
-		Payload: assignFailurePayload{
-			request,
-			err.Error(),
-		},
+func assignIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := assignRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Issue Assign Request [%s]: %s", input, err)
+			return newFailureEvent(assignFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Issue Assign Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(assignFailureEventDef, input, err)
+		}
+
+		if err := c.AssignIssue(req.IssueId, req.Username); err != nil {
+			log.Printf("Error assigning Issue %s to User %s: %s", req.IssueId, req.Username, err)
+			return newFailureEvent(assignFailureEventDef, input, err)
+		}
+		return flyte.Event{
+			EventDef: assignEventDef,
+			Payload:  req,
+		}
 	}
 }
@@ -2,9 +2,7 @@ package command
 
 import (
 	"encoding/json"
-	"errors"
 	"github.com/ExpediaGroup/flyte-client/flyte"
-	"github.com/ExpediaGroup/flyte-jira/client"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -15,29 +13,32 @@ type mockReqBody struct {
 	Name string `json:"name,omitempty"`
 }
 
-func createMockSendReq(testName string) func(request *http.Request) (int, error) {
-	return func(request *http.Request) (int, error) {
+func createMockSendReq(testName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
 		b, err := ioutil.ReadAll(request.Body)
 		if err != nil {
-			return 400, err
+			w.WriteHeader(400)
+			return
 		}
 
 		body := &mockReqBody{}
 		if err := json.Unmarshal(b, &body); err != nil {
-			return 400, err
+			w.WriteHeader(400)
+			return
 		}
 
 		if body.Name != testName {
-			return 404, errors.New("invalid name")
+			w.WriteHeader(404)
+			return
 		}
 
-		return 204, nil
+		w.WriteHeader(204)
 	}
 }
 func TestSuccessfulCommand(t *testing.T) {
-	client.SendRequestWithoutResp = createMockSendReq("test-123")
+	r := newTestRouter(t, createMockSendReq("test-123"))
 	input := []byte(`{"issueId":"foo","username":"test-123"}`)
-	actual := assignIssueHandler(input)
+	actual := assignIssueHandler(r)(input)
 	exp := flyte.Event{
 		EventDef: assignEventDef,
 		Payload: assignRequest{
@@ -52,13 +53,13 @@ func TestSuccessfulCommand(t *testing.T) {
 }
 
 /*
- When the name is nil, issue will be unassigned
- https://docs.atlassian.com/software/jira/docs/api/REST/7.6.1/#api/2/issue-assign
+When the name is nil, issue will be unassigned
+https://docs.atlassian.com/software/jira/docs/api/REST/7.6.1/#api/2/issue-assign
 */
 func TestSuccessfulCommandWithNoUser(t *testing.T) {
-	client.SendRequestWithoutResp = createMockSendReq("")
+	r := newTestRouter(t, createMockSendReq(""))
 	input := []byte(`{"issueId":"foo"}`)
-	actual := assignIssueHandler(input)
+	actual := assignIssueHandler(r)(input)
 	exp := flyte.Event{
 		EventDef: assignEventDef,
 		Payload: assignRequest{
@@ -72,16 +73,56 @@ func TestSuccessfulCommandWithNoUser(t *testing.T) {
 }
 
 func TestFailedCommand(t *testing.T) {
-	client.SendRequestWithoutResp = createMockSendReq("")
+	r := newTestRouter(t, createMockSendReq(""))
 	input := []byte(`{"issueId":"foo", "username":"test-123"}`)
-	actual := assignIssueHandler(input)
-	exp := newAssignFailureEvent(
-		assignRequest{
+	actual := assignIssueHandler(r)(input)
+	exp := flyte.Event{
+		EventDef: assignFailureEventDef,
+		Payload: failurePayload{
+			Code:           "NOT_FOUND",
+			Error:          "issueId=foo user=test-123 : statusCode=404",
+			failureDetails: failureDetails{StatusCode: 404},
+			Input:          json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actual, exp) {
+		t.Errorf("Expected: %v but got: %v", exp, actual)
+	}
+}
+
+func TestAssignBySlackHandle(t *testing.T) {
+	assign := createMockSendReq("jdoe")
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/user/assignable/search" {
+			w.Write([]byte(`[{"name": "jdoe", "emailAddress": "jane.doe@example.com"}]`))
+			return
+		}
+		assign(w, r)
+	})
+	input := []byte(`{"issueId":"foo","username":"<@U024BE7LH|jane.doe>"}`)
+
+	actual := assignIssueHandler(r)(input)
+
+	exp := flyte.Event{
+		EventDef: assignEventDef,
+		Payload: assignRequest{
 			IssueId:  "foo",
-			Username: "test-123",
+			Username: "<@U024BE7LH|jane.doe>",
 		},
-		errors.New("invalid name"))
+	}
 	if !reflect.DeepEqual(actual, exp) {
 		t.Errorf("Expected: %v but got: %v", exp, actual)
 	}
 }
+
+func TestAssignUnknownUser(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, []struct{}{}))
+	input := []byte(`{"issueId":"foo", "username":"nobody@example.com"}`)
+
+	payload := assignIssueHandler(r)(input).Payload.(failurePayload)
+
+	expected := `issueId=foo user=nobody@example.com : user not found: no user matches "nobody@example.com" among the users who can be assigned foo`
+	if payload.Code != "INVALID_INPUT" || payload.Error != expected {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
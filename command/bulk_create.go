@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+)
+
+var (
+	bulkCreateIssuesEventDef = flyte.EventDef{
+		Name: "BulkCreateIssues",
+	}
+
+	bulkCreateIssuesFailureEventDef = flyte.EventDef{
+		Name: "BulkCreateIssuesFailure",
+	}
+)
+
+func BulkCreateIssuesCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "BulkCreateIssues",
+		OutputEvents: []flyte.EventDef{bulkCreateIssuesEventDef, bulkCreateIssuesFailureEventDef},
+		Handler:      bulkCreateIssuesHandler(r),
+	}
+}
+
+type (
+	bulkCreateRequest struct {
+		// Issues have the same form as the input of CreateIssue. Issues
+		// without an instance go to the instance of the request, if any.
+		Issues []Input `json:"issues"`
+		instanceSelector
+	}
+
+	bulkCreatedPayload struct {
+		Created int              `json:"created"`
+		Failed  int              `json:"failed"`
+		Issues  []bulkCreateItem `json:"issues"`
+	}
+
+	// bulkCreateItem is the outcome for the issue at Index of the input,
+	// either its id and url or why it was not created.
+	bulkCreateItem struct {
+		Index   int    `json:"index"`
+		Summary string `json:"summary"`
+		Id      string `json:"id,omitempty"`
+		Url     string `json:"url,omitempty"`
+		*bulkCreateFailure
+	}
+
+	bulkCreateFailure struct {
+		Code      string `json:"code"`
+		Error     string `json:"error"`
+		Retryable bool   `json:"retryable"`
+		failureDetails
+	}
+)
+
+// bulkCreateIssuesHandler creates the issues of each Jira instance with as few
+// requests as possible. Issues that cannot be created do not fail the command,
+// they are reported next to the created ones.
+func bulkCreateIssuesHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := bulkCreateRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Bulk Create Issues Request [%s]: %s", input, err)
+			return newFailureEvent(bulkCreateIssuesFailureEventDef, input, invalidInput(err))
+		}
+		if len(req.Issues) == 0 {
+			return newFailureEvent(bulkCreateIssuesFailureEventDef, input, invalidInput(errors.New("issues are required")))
+		}
+
+		items := make([]bulkCreateItem, len(req.Issues))
+		var clients []*client.Client
+		batches := map[*client.Client][]int{}
+		for i, issue := range req.Issues {
+			items[i] = bulkCreateItem{Index: i, Summary: issue.Summary}
+			instance := issue.Instance
+			if instance == "" {
+				instance = req.Instance
+			}
+			if issue.DedupeKey != "" {
+				err := invalidInput(errors.New("dedupeKey is not supported when creating issues in bulk, use CreateIssue"))
+				items[i].bulkCreateFailure = newBulkCreateFailure(err)
+				continue
+			}
+			c, err := r.Route(instance, issue.Project)
+			if err != nil {
+				items[i].bulkCreateFailure = newBulkCreateFailure(err)
+				continue
+			}
+			if _, ok := batches[c]; !ok {
+				clients = append(clients, c)
+			}
+			batches[c] = append(batches[c], i)
+		}
+
+		for _, c := range clients {
+			batch := batches[c]
+			issues := make([]client.NewIssue, len(batch))
+			for n, i := range batch {
+				issues[n] = req.Issues[i].newIssue()
+			}
+			for n, result := range c.BulkCreateIssues(issues) {
+				item := &items[batch[n]]
+				if result.Err != nil {
+					log.Printf("Could not create issue %d of bulk creation: %s", item.Index, result.Err)
+					item.bulkCreateFailure = newBulkCreateFailure(result.Err)
+					continue
+				}
+				item.Id = result.Issue.Key
+				item.Url = fmt.Sprintf("%s/browse/%s", c.BrowseURL(), result.Issue.Key)
+			}
+		}
+
+		payload := bulkCreatedPayload{Issues: items}
+		for _, item := range items {
+			if item.bulkCreateFailure != nil {
+				payload.Failed++
+			} else {
+				payload.Created++
+			}
+		}
+		return flyte.Event{
+			EventDef: bulkCreateIssuesEventDef,
+			Payload:  payload,
+		}
+	}
+}
+
+func newBulkCreateFailure(err error) *bulkCreateFailure {
+	code, retryable := classifyFailure(err)
+	return &bulkCreateFailure{
+		Code:           code,
+		Error:          err.Error(),
+		Retryable:      retryable,
+		failureDetails: newFailureDetails(err),
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBulkCreateIssuesReportsEachIssue(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/issue/bulk" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"issues": [{"key": "FLYTE-1"}], "errors": [{"status": 400,
+			"elementErrors": {"errors": {"components": "Component name 'nope' is not valid"}}, "failedElementNumber": 1}]}`))
+	})
+	input := []byte(`{"issues": [
+		{"project": "FLYTE", "issuetype": "Task", "summary": "first"},
+		{"project": "FLYTE", "issuetype": "Task", "summary": "second", "components": ["nope"]},
+		{"project": "FLYTE", "issuetype": "Task", "summary": "third", "instance": "other"}
+	]}`)
+
+	actualEvent := bulkCreateIssuesHandler(singleInstanceRouter(t, c))(input)
+
+	expectedPayload := bulkCreatedPayload{
+		Created: 1,
+		Failed:  2,
+		Issues: []bulkCreateItem{
+			{Index: 0, Summary: "first", Id: "FLYTE-1", Url: c.Host() + "/browse/FLYTE-1"},
+			{Index: 1, Summary: "second", bulkCreateFailure: &bulkCreateFailure{
+				Code:           "BAD_REQUEST",
+				Error:          "issueSummary='second' : statusCode=400 : components: Component name 'nope' is not valid",
+				failureDetails: failureDetails{StatusCode: 400, FieldErrors: map[string]string{"components": "Component name 'nope' is not valid"}},
+			}},
+			{Index: 2, Summary: "third", bulkCreateFailure: &bulkCreateFailure{
+				Code:  "UNKNOWN_INSTANCE",
+				Error: `unknown jira instance "other"`,
+			}},
+		},
+	}
+	if actualEvent.EventDef != bulkCreateIssuesEventDef || !reflect.DeepEqual(actualEvent.Payload, expectedPayload) {
+		t.Errorf("Expected: %+v but got: %+v", expectedPayload, actualEvent.Payload)
+	}
+}
+
+func TestBulkCreateIssuesPayloadOmitsFailureOfCreatedIssues(t *testing.T) {
+	b, err := json.Marshal(bulkCreateItem{Index: 0, Summary: "first", Id: "FLYTE-1", Url: "https://jira/browse/FLYTE-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"index":0,"summary":"first","id":"FLYTE-1","url":"https://jira/browse/FLYTE-1"}`
+	if string(b) != expected {
+		t.Errorf("Expected: %s but got: %s", expected, b)
+	}
+}
+
+func TestBulkCreateIssuesWithoutIssues(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusCreated, struct{}{}))
+
+	payload := bulkCreateIssuesHandler(r)([]byte(`{"issues": []}`)).Payload.(failurePayload)
+
+	if payload.Code != "INVALID_INPUT" || payload.Error != "issues are required" {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+)
+
+const defaultClonePrefix = "CLONE - "
+
+var (
+	issueClonedEventDef = flyte.EventDef{
+		Name: "IssueCloned",
+	}
+
+	issueCloneFailureEventDef = flyte.EventDef{
+		Name: "IssueCloneFailure",
+	}
+)
+
+func CloneIssueCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "CloneIssue",
+		OutputEvents: []flyte.EventDef{issueClonedEventDef, issueCloneFailureEventDef},
+		Handler:      cloneIssueHandler(r),
+	}
+}
+
+type (
+	cloneIssueRequest struct {
+		IssueId       string   `json:"issueId"`
+		SummaryPrefix *string  `json:"summaryPrefix"`
+		Project       string   `json:"project"`
+		CustomFields  []string `json:"customFields"`
+		CloneSubtasks bool     `json:"cloneSubtasks"`
+		CloneLinks    bool     `json:"cloneLinks"`
+		instanceSelector
+	}
+
+	issueClonedPayload struct {
+		IssueId  string   `json:"issueId"`
+		Key      string   `json:"key"`
+		Url      string   `json:"url"`
+		Subtasks []string `json:"subtasks,omitempty"`
+	}
+)
+
+// cloneIssueHandler clones an issue, the summary of the clone being prefixed
+// with "CLONE - " unless summaryPrefix is given, even empty.
+func cloneIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := cloneIssueRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Clone Issue Request [%s]: %s", input, err)
+			return newFailureEvent(issueCloneFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" {
+			return newFailureEvent(issueCloneFailureEventDef, input, invalidInput(errors.New("issueId is required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Clone Issue Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(issueCloneFailureEventDef, input, err)
+		}
+
+		options := client.CloneOptions{
+			SummaryPrefix: defaultClonePrefix,
+			Project:       req.Project,
+			CustomFields:  req.CustomFields,
+			Subtasks:      req.CloneSubtasks,
+			Links:         req.CloneLinks,
+		}
+		if req.SummaryPrefix != nil {
+			options.SummaryPrefix = *req.SummaryPrefix
+		}
+		result, err := c.CloneIssue(req.IssueId, options)
+		if err != nil {
+			err = fmt.Errorf("Could not clone issue: %w", err)
+			log.Println(err)
+			return newFailureEvent(issueCloneFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: issueClonedEventDef,
+			Payload: issueClonedPayload{
+				IssueId:  req.IssueId,
+				Key:      result.Key,
+				Url:      fmt.Sprintf("%s/browse/%s", c.BrowseURL(), result.Key),
+				Subtasks: result.Subtasks,
+			},
+		}
+	}
+}
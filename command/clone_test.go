@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCloneIssueAsExpected(t *testing.T) {
+	var summary string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/issue/FLYTE-1":
+			w.Write([]byte(`{"key": "FLYTE-1", "fields": {"summary": "deploy the pack", "project": {"key": "FLYTE"}, "issuetype": {"name": "Task"}}}`))
+		case "/rest/api/2/issue/":
+			var body struct {
+				Fields struct {
+					Summary string `json:"summary"`
+				} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			summary = body.Fields.Summary
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-2"}`))
+		case "/rest/api/2/issueLink":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	actualEvent := cloneIssueHandler(singleInstanceRouter(t, c))([]byte(`{"issueId": "FLYTE-1"}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: issueClonedEventDef,
+		Payload: issueClonedPayload{
+			IssueId: "FLYTE-1",
+			Key:     "FLYTE-2",
+			Url:     c.Host() + "/browse/FLYTE-2",
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	if summary != "CLONE - deploy the pack" {
+		t.Errorf("Expected the clone to be prefixed but got: %q", summary)
+	}
+}
+
+func TestCloneIssueWithEmptyPrefix(t *testing.T) {
+	var summary string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/issue/FLYTE-1":
+			w.Write([]byte(`{"key": "FLYTE-1", "fields": {"summary": "deploy the pack", "project": {"key": "FLYTE"}, "issuetype": {"name": "Task"}}}`))
+		case "/rest/api/2/issue/":
+			var body struct {
+				Fields struct {
+					Summary string `json:"summary"`
+				} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			summary = body.Fields.Summary
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-2"}`))
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	})
+
+	cloneIssueHandler(singleInstanceRouter(t, c))([]byte(`{"issueId": "FLYTE-1", "summaryPrefix": ""}`))
+
+	if summary != "deploy the pack" {
+		t.Errorf("Expected the summary to be kept but got: %q", summary)
+	}
+}
+
+func TestCloneIssueWithoutIssueId(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
+	input := []byte(`{"cloneSubtasks": true}`)
+
+	actualEvent := cloneIssueHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: issueCloneFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "issueId is required",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
@@ -18,38 +18,86 @@ package command
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ExpediaGroup/flyte-client/flyte"
 	"github.com/ExpediaGroup/flyte-jira/client"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"github.com/ExpediaGroup/flyte-jira/markup"
 	"log"
 )
 
-var IssueCommentCommand = flyte.Command{
-	Name:         "CommentIssue",
-	OutputEvents: []flyte.EventDef{commentEventDef, commentFailureEventDef},
-	Handler:      commentHandler,
+func IssueCommentCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "CommentIssue",
+		OutputEvents: []flyte.EventDef{commentEventDef, commentFailureEventDef},
+		Handler:      commentHandler(r),
+	}
 }
 
-func commentHandler(input json.RawMessage) flyte.Event {
-	var handlerInput struct {
-		Id      string `json:"id"`
-		Comment string `json:"comment"`
-	}
+func commentHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		var handlerInput struct {
+			Id              string             `json:"id"`
+			Comment         string             `json:"comment"`
+			Visibility      *domain.Visibility `json:"visibility"`
+			ResolveMentions bool               `json:"resolveMentions"`
+			Format          string             `json:"format"`
+			instanceSelector
+		}
 
-	if err := json.Unmarshal(input, &handlerInput); err != nil {
-		err = fmt.Errorf("Could not marshal comment into json: %s", err)
-		log.Println(err)
-		return newCommentFailureEvent(err.Error(), "unknown", "unkown")
-	}
+		if err := json.Unmarshal(input, &handlerInput); err != nil {
+			err = fmt.Errorf("Could not marshal comment into json: %s", err)
+			log.Println(err)
+			return newFailureEvent(commentFailureEventDef, input, invalidInput(err))
+		}
+		if err := validateVisibility(handlerInput.Visibility); err != nil {
+			return newFailureEvent(commentFailureEventDef, input, invalidInput(err))
+		}
+		if err := markup.CheckFormat(handlerInput.Format); err != nil {
+			return newFailureEvent(commentFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(handlerInput.Instance, handlerInput.Id)
+		if err != nil {
+			log.Println(err)
+			return newFailureEvent(commentFailureEventDef, input, err)
+		}
+
+		created, err := c.AddComment(handlerInput.Id, client.NewComment{
+			Body:            handlerInput.Comment,
+			Visibility:      handlerInput.Visibility,
+			ResolveMentions: handlerInput.ResolveMentions,
+			Format:          handlerInput.Format,
+		})
+		if err != nil {
+			err = fmt.Errorf("Could not leave comment: %w", err)
+			log.Println(err)
+			return newFailureEvent(commentFailureEventDef, input, err)
+		}
 
-	_, err := client.CommentIssue(handlerInput.Id, handlerInput.Comment)
-	if err != nil {
-		err = fmt.Errorf("Could not leave comment: %s", err)
-		log.Println(err)
-		return newCommentFailureEvent(err.Error(), handlerInput.Id, handlerInput.Comment)
+		comment := handlerInput.Comment
+		if created.Body != "" {
+			// the comment as posted, with the mentions resolved
+			comment = created.Body
+		}
+		return newCommentEvent(handlerInput.Id, comment, created.ID, commentURL(c, handlerInput.Id, created.ID))
 	}
+}
 
-	return newCommentEvent(handlerInput.Id, handlerInput.Comment)
+// validateVisibility checks that a comment is restricted to a project role or
+// to a group, when it is restricted.
+func validateVisibility(visibility *domain.Visibility) error {
+	if visibility == nil {
+		return nil
+	}
+	if visibility.Type != "role" && visibility.Type != "group" {
+		return fmt.Errorf("visibility type must be role or group, got %q", visibility.Type)
+	}
+	if visibility.Value == "" {
+		return errors.New("visibility value must name the role or group")
+	}
+	return nil
 }
 
 var commentEventDef = flyte.EventDef{
@@ -57,37 +105,244 @@ var commentEventDef = flyte.EventDef{
 }
 
 type commentSuccessPayload struct {
-	Id      string `json:"id"`
-	Comment string `json:"comment"`
+	Id        string `json:"id"`
+	Comment   string `json:"comment"`
+	CommentId string `json:"commentId"`
+	Url       string `json:"url"`
 }
 
 var commentFailureEventDef = flyte.EventDef{
 	Name: "CommentFailure",
 }
 
-type commentFailurePayload struct {
-	Id      string `json:"id"`
-	Comment string `json:"comment"`
-	Error   string `json:"error"`
-}
-
-func newCommentFailureEvent(error, id, comment string) flyte.Event {
+func newCommentEvent(id, comment, commentId, url string) flyte.Event {
 	return flyte.Event{
-		EventDef: commentFailureEventDef,
-		Payload: commentFailurePayload{
-			Id:      id,
-			Comment: comment,
-			Error:   error,
+		EventDef: commentEventDef,
+		Payload: commentSuccessPayload{
+			Id:        id,
+			Comment:   comment,
+			CommentId: commentId,
+			Url:       url,
 		},
 	}
 }
 
-func newCommentEvent(id, comment string) flyte.Event {
-	return flyte.Event{
-		EventDef: commentEventDef,
-		Payload: commentSuccessPayload{
-			Id:      id,
-			Comment: comment,
-		},
+// commentURL returns the link showing the comment commentId of the issue
+// issueId.
+func commentURL(c *client.Client, issueId, commentId string) string {
+	return fmt.Sprintf("%s/browse/%s?focusedCommentId=%s#comment-%s", c.BrowseURL(), issueId, commentId, commentId)
+}
+
+var (
+	commentsEventDef = flyte.EventDef{
+		Name: "Comments",
+	}
+
+	commentsFailureEventDef = flyte.EventDef{
+		Name: "CommentsFailure",
+	}
+
+	commentEditedEventDef = flyte.EventDef{
+		Name: "CommentEdited",
+	}
+
+	commentEditFailureEventDef = flyte.EventDef{
+		Name: "CommentEditFailure",
+	}
+
+	commentDeletedEventDef = flyte.EventDef{
+		Name: "CommentDeleted",
+	}
+
+	commentDeleteFailureEventDef = flyte.EventDef{
+		Name: "CommentDeleteFailure",
+	}
+)
+
+func ListCommentsCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "ListComments",
+		OutputEvents: []flyte.EventDef{commentsEventDef, commentsFailureEventDef},
+		Handler:      listCommentsHandler(r),
+	}
+}
+
+func EditCommentCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "EditComment",
+		OutputEvents: []flyte.EventDef{commentEditedEventDef, commentEditFailureEventDef},
+		Handler:      editCommentHandler(r),
+	}
+}
+
+func DeleteCommentCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "DeleteComment",
+		OutputEvents: []flyte.EventDef{commentDeletedEventDef, commentDeleteFailureEventDef},
+		Handler:      deleteCommentHandler(r),
+	}
+}
+
+type (
+	listCommentsRequest struct {
+		IssueId     string `json:"issueId"`
+		StartIndex  int    `json:"startIndex"`
+		MaxResults  int    `json:"maxResults"`
+		NewestFirst bool   `json:"newestFirst"`
+		instanceSelector
+	}
+
+	commentsPayload struct {
+		IssueId      string           `json:"issueId"`
+		StartIndex   int              `json:"startIndex"`
+		MaxResults   int              `json:"maxResults"`
+		TotalResults int              `json:"total"`
+		Comments     []CommentPayload `json:"comments"`
+	}
+
+	CommentPayload struct {
+		Id         string             `json:"id"`
+		Url        string             `json:"url"`
+		Author     string             `json:"author"`
+		Body       string             `json:"body"`
+		Created    string             `json:"created"`
+		Updated    string             `json:"updated,omitempty"`
+		Visibility *domain.Visibility `json:"visibility,omitempty"`
+	}
+
+	commentRequest struct {
+		IssueId   string `json:"issueId"`
+		CommentId string `json:"commentId"`
+		Comment   string `json:"comment"`
+		Format    string `json:"format"`
+		instanceSelector
+	}
+
+	commentChangedPayload struct {
+		IssueId   string `json:"issueId"`
+		CommentId string `json:"commentId"`
+		Comment   string `json:"comment,omitempty"`
+		Url       string `json:"url,omitempty"`
+	}
+)
+
+func listCommentsHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := listCommentsRequest{MaxResults: 50}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling List Comments Request [%s]: %s", input, err)
+			return newFailureEvent(commentsFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" {
+			return newFailureEvent(commentsFailureEventDef, input, invalidInput(errors.New("issueId is required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing List Comments Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(commentsFailureEventDef, input, err)
+		}
+
+		result, err := c.ListComments(req.IssueId, req.StartIndex, req.MaxResults, req.NewestFirst)
+		if err != nil {
+			err = fmt.Errorf("Could not list comments: %w", err)
+			log.Println(err)
+			return newFailureEvent(commentsFailureEventDef, input, err)
+		}
+
+		comments := []CommentPayload{}
+		for _, comment := range result.Comments {
+			author := comment.Author.Name
+			if author == "" {
+				author = comment.Author.AccountID
+			}
+			comments = append(comments, CommentPayload{
+				Id:         comment.ID,
+				Url:        commentURL(c, req.IssueId, comment.ID),
+				Author:     author,
+				Body:       comment.Body,
+				Created:    comment.Created,
+				Updated:    comment.Updated,
+				Visibility: comment.Visibility,
+			})
+		}
+		return flyte.Event{
+			EventDef: commentsEventDef,
+			Payload: commentsPayload{
+				IssueId:      req.IssueId,
+				StartIndex:   result.StartIndex,
+				MaxResults:   result.MaxResults,
+				TotalResults: result.TotalResults,
+				Comments:     comments,
+			},
+		}
+	}
+}
+
+func editCommentHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := commentRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Edit Comment Request [%s]: %s", input, err)
+			return newFailureEvent(commentEditFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" || req.CommentId == "" {
+			return newFailureEvent(commentEditFailureEventDef, input, invalidInput(errors.New("issueId and commentId are required")))
+		}
+		if err := markup.CheckFormat(req.Format); err != nil {
+			return newFailureEvent(commentEditFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Edit Comment Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(commentEditFailureEventDef, input, err)
+		}
+
+		if _, err := c.EditComment(req.IssueId, req.CommentId, req.Comment, req.Format); err != nil {
+			err = fmt.Errorf("Could not edit comment: %w", err)
+			log.Println(err)
+			return newFailureEvent(commentEditFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: commentEditedEventDef,
+			Payload: commentChangedPayload{
+				IssueId:   req.IssueId,
+				CommentId: req.CommentId,
+				Comment:   req.Comment,
+				Url:       commentURL(c, req.IssueId, req.CommentId),
+			},
+		}
+	}
+}
+
+func deleteCommentHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := commentRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Delete Comment Request [%s]: %s", input, err)
+			return newFailureEvent(commentDeleteFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" || req.CommentId == "" {
+			return newFailureEvent(commentDeleteFailureEventDef, input, invalidInput(errors.New("issueId and commentId are required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Delete Comment Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(commentDeleteFailureEventDef, input, err)
+		}
+
+		if err := c.DeleteComment(req.IssueId, req.CommentId); err != nil {
+			err = fmt.Errorf("Could not delete comment: %w", err)
+			log.Println(err)
+			return newFailureEvent(commentDeleteFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: commentDeletedEventDef,
+			Payload:  commentChangedPayload{IssueId: req.IssueId, CommentId: req.CommentId},
+		}
 	}
 }
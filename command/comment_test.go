@@ -17,16 +17,21 @@ limitations under the License.
 package command
 
 import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/flyte"
 	"github.com/ExpediaGroup/flyte-jira/client"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestSucessfulComment(t *testing.T) {
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusCreated, nil
-	}
+	c := newTestClient(t, respondWith(http.StatusCreated, map[string]string{"id": "10001"}))
+	r := singleInstanceRouter(t, c)
 	var inputStruct = struct {
 		Id      string `json:"id"`
 		Comment string `json:"comment"`
@@ -34,17 +39,15 @@ func TestSucessfulComment(t *testing.T) {
 
 	input := toJson(inputStruct, t)
 
-	actualEvent := commentHandler(input)
-	expectedEvent := newCommentEvent("TEST-123", "test comment")
+	actualEvent := commentHandler(r)(input)
+	expectedEvent := newCommentEvent("TEST-123", "test comment", "10001", c.Host()+"/browse/TEST-123?focusedCommentId=10001#comment-10001")
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %v but got: %v", expectedEvent, actualEvent)
 	}
 }
 
 func TestFailedComment(t *testing.T) {
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusBadRequest, nil
-	}
+	r := newTestRouter(t, respondWith(http.StatusBadRequest, struct{}{}))
 
 	var inputStruct = struct {
 		Id      string `json:"id"`
@@ -52,8 +55,259 @@ func TestFailedComment(t *testing.T) {
 	}{"TEST-123", "test comment"}
 	input := toJson(inputStruct, t)
 
-	actualEvent := commentHandler(input)
-	expectedEvent := newCommentFailureEvent("Could not leave comment: issueId=TEST-123 : statusCode=400", "TEST-123", "test comment")
+	actualEvent := commentHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: commentFailureEventDef,
+		Payload: failurePayload{
+			Code:           "BAD_REQUEST",
+			Error:          "Could not leave comment: issueId=TEST-123 : statusCode=400",
+			failureDetails: failureDetails{StatusCode: 400},
+			Input:          json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %v but got: %v", expectedEvent, actualEvent)
+	}
+}
+
+func TestFailedCommentReportsRetries(t *testing.T) {
+	server := httptest.NewServer(respondWith(http.StatusServiceUnavailable, nil))
+	defer server.Close()
+	c, err := client.New(client.Config{
+		Host:  server.URL,
+		Retry: client.RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond, MaxElapsed: time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualEvent := commentHandler(singleInstanceRouter(t, c))([]byte(`{"id": "TEST-123", "comment": "test comment"}`))
+
+	payload := actualEvent.Payload.(failurePayload)
+	if payload.Code != "JIRA_UNAVAILABLE" || !payload.Retryable {
+		t.Errorf("Expected a retryable JIRA_UNAVAILABLE failure but got: %+v", payload)
+	}
+	expectedDetails := failureDetails{StatusCode: 503, Retries: 1, RetryReason: "503 Service Unavailable"}
+	if !reflect.DeepEqual(payload.failureDetails, expectedDetails) {
+		t.Errorf("Expected: %+v but got: %+v", expectedDetails, payload.failureDetails)
+	}
+}
+
+func TestListCommentsAsExpected(t *testing.T) {
+	var query string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"startAt": 0, "maxResults": 50, "total": 2, "comments": [
+			{"id": "10002", "author": {"name": "jdoe"}, "body": "rolled back", "created": "2020-01-02T10:00:00.000+0000",
+				"visibility": {"type": "role", "value": "Developers"}},
+			{"id": "10001", "author": {"accountId": "5b10a"}, "body": "deploying", "created": "2020-01-01T10:00:00.000+0000",
+				"updated": "2020-01-01T11:00:00.000+0000"}
+		]}`))
+	})
+
+	actualEvent := listCommentsHandler(singleInstanceRouter(t, c))([]byte(`{"issueId": "TEST-123", "newestFirst": true}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: commentsEventDef,
+		Payload: commentsPayload{
+			IssueId:      "TEST-123",
+			MaxResults:   50,
+			TotalResults: 2,
+			Comments: []CommentPayload{
+				{
+					Id:         "10002",
+					Url:        c.Host() + "/browse/TEST-123?focusedCommentId=10002#comment-10002",
+					Author:     "jdoe",
+					Body:       "rolled back",
+					Created:    "2020-01-02T10:00:00.000+0000",
+					Visibility: &domain.Visibility{Type: "role", Value: "Developers"},
+				},
+				{
+					Id:      "10001",
+					Url:     c.Host() + "/browse/TEST-123?focusedCommentId=10001#comment-10001",
+					Author:  "5b10a",
+					Body:    "deploying",
+					Created: "2020-01-01T10:00:00.000+0000",
+					Updated: "2020-01-01T11:00:00.000+0000",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	if query != "startAt=0&maxResults=50&orderBy=-created" {
+		t.Errorf("Expected the newest comments first but got query: %s", query)
+	}
+}
+
+func TestEditCommentAsExpected(t *testing.T) {
+	var method, path, body string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(b)
+		w.Write(b)
+	})
+
+	actualEvent := editCommentHandler(singleInstanceRouter(t, c))([]byte(`{"issueId": "TEST-123", "commentId": "10001", "comment": "rolled back"}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: commentEditedEventDef,
+		Payload: commentChangedPayload{
+			IssueId:   "TEST-123",
+			CommentId: "10001",
+			Comment:   "rolled back",
+			Url:       c.Host() + "/browse/TEST-123?focusedCommentId=10001#comment-10001",
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	if method != http.MethodPut || path != "/rest/api/2/issue/TEST-123/comment/10001" || body != `{"body":"rolled back"}` {
+		t.Errorf("Unexpected request: %s %s %s", method, path, body)
+	}
+}
+
+func TestEditCommentInMarkdownIsPostedAsWiki(t *testing.T) {
+	var body string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write(b)
+	})
+
+	actualEvent := editCommentHandler(singleInstanceRouter(t, c))([]byte(`{"issueId": "TEST-123", "commentId": "10001", "comment": "**rolled** back", "format": "markdown"}`))
+
+	if actualEvent.EventDef != commentEditedEventDef {
+		t.Errorf("Expected a %s event but got: %+v", commentEditedEventDef.Name, actualEvent)
+	}
+	if body != `{"body":"*rolled* back"}` {
+		t.Errorf("Unexpected request body: %s", body)
+	}
+}
+
+func TestDeleteCommentAsExpected(t *testing.T) {
+	var method, path string
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	actualEvent := deleteCommentHandler(r)([]byte(`{"issueId": "TEST-123", "commentId": "10001"}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: commentDeletedEventDef,
+		Payload:  commentChangedPayload{IssueId: "TEST-123", CommentId: "10001"},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	if method != http.MethodDelete || path != "/rest/api/2/issue/TEST-123/comment/10001" {
+		t.Errorf("Unexpected request: %s %s", method, path)
+	}
+}
+
+func TestDeleteCommentWithoutCommentId(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusNoContent, nil))
+	input := []byte(`{"issueId": "TEST-123"}`)
+
+	actualEvent := deleteCommentHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: commentDeleteFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "issueId and commentId are required",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestCommentWithInvalidVisibility(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusCreated, struct{}{}))
+	input := []byte(`{"id": "TEST-123", "comment": "test comment", "visibility": {"type": "user", "value": "jdoe"}}`)
+
+	actualEvent := commentHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: commentFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: `visibility type must be role or group, got "user"`,
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %v but got: %v", expectedEvent, actualEvent)
+	}
+}
+
+func TestCommentMentioningUnknownUser(t *testing.T) {
+	posted := false
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/user/search" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		posted = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	input := []byte(`{"id": "TEST-123", "comment": "@nobody please check", "resolveMentions": true}`)
+
+	actualEvent := commentHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: commentFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "Could not leave comment: issueId=TEST-123 : user not found: no single user matches @nobody",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %v but got: %v", expectedEvent, actualEvent)
+	}
+	if posted {
+		t.Error("Expected no comment to be posted")
+	}
+}
+
+func TestCommentInMarkdownIsPostedAsWiki(t *testing.T) {
+	var body map[string]interface{}
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "10001"}`))
+	})
+	input := []byte(`{"id": "TEST-123", "comment": "see [the docs](https://example.com) **now**", "format": "markdown"}`)
+
+	actualEvent := commentHandler(r)(input)
+
+	if actualEvent.EventDef != commentEventDef {
+		t.Fatalf("Unexpected event: %+v", actualEvent)
+	}
+	if body["body"] != "see [the docs|https://example.com] *now*" {
+		t.Errorf("Unexpected comment body: %v", body["body"])
+	}
+}
+
+func TestCommentWithUnknownFormat(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusCreated, struct{}{}))
+	input := []byte(`{"id": "TEST-123", "comment": "test comment", "format": "html"}`)
+
+	actualEvent := commentHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: commentFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: `unknown format "html", use wiki, markdown, mrkdwn or adf`,
+			Input: json.RawMessage(input),
+		},
+	}
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %v but got: %v", expectedEvent, actualEvent)
 	}
@@ -26,93 +26,175 @@ import (
 )
 
 type Input struct {
-	Project     string   `json:"project"`
-	IssueType   string   `json:"issuetype"`
-	Summary     string   `json:"summary"`
-	Description string   `json:"description"`
-	Labels      []string `json:"labels"`
-	Inc         string   `json:"incident"`
-	Priority    string   `json:"priority"`
-	Reporter    string   `json:"reporter"`
+	Project         string   `json:"project"`
+	IssueType       string   `json:"issuetype"`
+	Summary         string   `json:"summary"`
+	Description     string   `json:"description"`
+	Labels          []string `json:"labels"`
+	Inc             string   `json:"incident"`
+	Priority        string   `json:"priority"`
+	Reporter        string   `json:"reporter"`
+	Assignee        string   `json:"assignee"`
+	Components      []string `json:"components"`
+	FixVersions     []string `json:"fixVersions"`
+	AffectsVersions []string `json:"affectsVersions"`
+	DueDate         string   `json:"duedate"`
+	Environment     string   `json:"environment"`
+	Parent          string   `json:"parent"`
+	EpicLink        string   `json:"epicLink"`
+	// DedupeKey identifies the issue across retries of a flow: when an
+	// issue was created with the same key, it is returned instead of
+	// creating another one.
+	DedupeKey string `json:"dedupeKey"`
+	// CustomFields sets further fields by name, e.g. "Story Points": 3
+	CustomFields map[string]interface{} `json:"customFields"`
+	// Format is the format of the description: wiki (the default),
+	// markdown or mrkdwn.
+	Format string `json:"format"`
+	instanceSelector
 }
 
-var CreateIssueCommand = flyte.Command{
-	Name:         "CreateIssue",
-	OutputEvents: []flyte.EventDef{createIssueEventDef, createIssueFailureEventDef},
-	Handler:      createIssueHandler,
+func (i Input) newIssue() client.NewIssue {
+	return client.NewIssue{
+		Project:         i.Project,
+		IssueType:       i.IssueType,
+		Summary:         i.Summary,
+		Description:     i.Description,
+		Priority:        i.Priority,
+		Reporter:        i.Reporter,
+		Assignee:        i.Assignee,
+		Labels:          i.Labels,
+		Components:      i.Components,
+		FixVersions:     i.FixVersions,
+		AffectsVersions: i.AffectsVersions,
+		DueDate:         i.DueDate,
+		Environment:     i.Environment,
+		Parent:          i.Parent,
+		EpicLink:        i.EpicLink,
+		CustomFields:    i.CustomFields,
+		Format:          i.Format,
+	}
 }
 
-var CreateIncIssueCommand = flyte.Command{
-	Name:         "CreateIncIssue",
-	OutputEvents: []flyte.EventDef{createIncIssueEventDef, createIncIssueFailureEventDef},
-	Handler:      createIncIssueHandler,
+func CreateIssueCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "CreateIssue",
+		OutputEvents: []flyte.EventDef{createIssueEventDef, createIssueFailureEventDef},
+		Handler:      createIssueHandler(r),
+	}
 }
 
-func createIssueHandler(input json.RawMessage) flyte.Event {
-	handlerInput := Input{}
-	if err := json.Unmarshal(input, &handlerInput); err != nil {
-		err := fmt.Errorf("Could not marshal create client issue input: %s", err)
-		log.Println(err)
-		return newCreateIssueFailureEvent(err.Error(), "unknown", "unknown", "unkown")
+func CreateIncIssueCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "CreateIncIssue",
+		OutputEvents: []flyte.EventDef{createIncIssueEventDef, createIncIssueFailureEventDef},
+		Handler:      createIncIssueHandler(r),
 	}
-	if (handlerInput.Summary == "" || handlerInput.Description == "") && (handlerInput.Project == "RCPSUP") {
-		err := fmt.Errorf("Please provide both issue title & description. mandatory fields missing!  ")
-		log.Println(err)
-		return newCreateIssueFailureEvent(err.Error(), handlerInput.Project, handlerInput.Description, handlerInput.Summary)
+}
+
+func createIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		handlerInput := Input{}
+		if err := json.Unmarshal(input, &handlerInput); err != nil {
+			err := fmt.Errorf("Could not marshal create client issue input: %s", err)
+			log.Println(err)
+			return newFailureEvent(createIssueFailureEventDef, input, invalidInput(err))
+		}
+		c, err := r.Route(handlerInput.Instance, handlerInput.Project)
+		if err != nil {
+			log.Println(err)
+			return newFailureEvent(createIssueFailureEventDef, input, err)
+		}
+		issue, duplicate, err := createDeduplicated(c, handlerInput.Project, handlerInput.DedupeKey, func(labels []string) (client.CreateIssueAPIResponse, error) {
+			newIssue := handlerInput.newIssue()
+			newIssue.Labels = append(newIssue.Labels, labels...)
+			issue, err := c.CreateIssue(newIssue)
+			return client.CreateIssueAPIResponse{ID: issue.ID, Key: issue.Key, Self: issue.Self}, err
+		})
+		if err != nil {
+			err = fmt.Errorf("Could not create issue: %w", err)
+			log.Println(err)
+			return newFailureEvent(createIssueFailureEventDef, input, err)
+		}
+		return newCreateIssueEvent(fmt.Sprintf("%s/browse/%s", c.BrowseURL(), issue.Key), issue.Key, handlerInput, duplicate)
 	}
-	issue, err := client.CreateIssue(handlerInput.Project, handlerInput.IssueType, handlerInput.Summary, handlerInput.Description, handlerInput.Priority, handlerInput.Reporter)
-	if err != nil {
-		err = fmt.Errorf("Could not create issue: %v", err)
-		log.Println(err)
-		return newCreateIssueFailureEvent(err.Error(), handlerInput.Project, handlerInput.IssueType, handlerInput.Summary)
+}
+
+// createDeduplicated calls create, which is given the labels to add to the
+// issue, unless an issue was created for dedupeKey in the project already. It
+// returns the created or the existing issue, and whether it existed.
+func createDeduplicated(c *client.Client, project, dedupeKey string, create func(labels []string) (client.CreateIssueAPIResponse, error)) (client.CreateIssueAPIResponse, bool, error) {
+	if dedupeKey == "" {
+		issue, err := create(nil)
+		return issue, false, err
 	}
-	return newCreateIssueEvent(fmt.Sprintf("%s/browse/%s", client.JiraConfig.Host, issue.Key), issue.Key, handlerInput.Project, handlerInput.IssueType, handlerInput.Summary, handlerInput.Description, handlerInput.Priority, handlerInput.Reporter)
+
+	var created client.CreateIssueAPIResponse
+	existing, err := c.CreateOnce(project, dedupeKey, func(label string) (client.CreateIssueAPIResponse, error) {
+		var err error
+		created, err = create([]string{label})
+		return created, err
+	})
+	if existing != nil {
+		log.Printf("Issue %s was created for dedupeKey %s already", existing.Key, dedupeKey)
+		return *existing, true, nil
+	}
+	return created, false, err
 }
 
 // createIncIssueHandler handles CreateIncIssue IMBot command and returns success/fail flyte.Event
-func createIncIssueHandler(input json.RawMessage) flyte.Event {
-	handlerInput := Input{}
-	if err := json.Unmarshal(input, &handlerInput); err != nil {
-		err := fmt.Errorf("could not marshal create client issue input: %s", err)
-		log.Println(err)
-		return newCreateIssueFailureEvent(err.Error(), "unknown", "unknown", "unknown")
-	}
-	log.Println(fmt.Sprintf("Create JIRA issue command recieved. Params: %+v", handlerInput))
+func createIncIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		handlerInput := Input{}
+		if err := json.Unmarshal(input, &handlerInput); err != nil {
+			err := fmt.Errorf("could not marshal create client issue input: %s", err)
+			log.Println(err)
+			return newFailureEvent(createIncIssueFailureEventDef, input, invalidInput(err))
+		}
+		log.Println(fmt.Sprintf("Create JIRA issue command recieved. Params: %+v", handlerInput))
 
-	// Input validation
-	if !incidentPattern(handlerInput.Inc) { // inc name should be valid
-		return flyte.Event{
-			EventDef: createIncIssueFailureEventDef,
-			Payload: CreateIncIssueFailure{
-				Message: fmt.Sprintf("%s: invalid incident number format", handlerInput.Inc),
-			},
+		// Input validation
+		if !incidentPattern(handlerInput.Inc) { // inc name should be valid
+			err := fmt.Errorf("%s: invalid incident number format", handlerInput.Inc)
+			return newFailureEvent(createIncIssueFailureEventDef, input, invalidInput(err))
 		}
-	}
 
-	if len(handlerInput.Summary) > 255 { // JIRA summary symbols limit is 255
-		return flyte.Event{
-			EventDef: createIncIssueFailureEventDef,
-			Payload: CreateIncIssueFailure{
-				Message: fmt.Sprintf("Too long summary (lenght %d). Limit is 255 symbols", len(handlerInput.Summary)),
-			},
+		c, err := r.Route(handlerInput.Instance, handlerInput.Project)
+		if err != nil {
+			log.Println(err)
+			return newFailureEvent(createIncIssueFailureEventDef, input, err)
 		}
-	}
 
-	issue, err := client.CreateCustomIssue(handlerInput.Project, handlerInput.IssueType, handlerInput.Summary,
-		handlerInput.Description, handlerInput.Labels)
-	if err != nil {
-		err = fmt.Errorf("could not create issue: %v", err)
-		log.Println(err)
-		return newCreateIssueFailureEvent(err.Error(), handlerInput.Project, handlerInput.IssueType, handlerInput.Summary)
-	}
+		dedupeKey := handlerInput.DedupeKey
+		if dedupeKey == "" {
+			dedupeKey = handlerInput.Inc
+		}
+		issue, duplicate, err := createDeduplicated(c, handlerInput.Project, dedupeKey, func(labels []string) (client.CreateIssueAPIResponse, error) {
+			return c.CreateCustomIssue(handlerInput.Project, handlerInput.IssueType, handlerInput.Summary,
+				handlerInput.Description, handlerInput.Format, handlerInput.Inc, append(handlerInput.Labels, labels...))
+		})
+		if err != nil {
+			err = fmt.Errorf("could not create issue: %w", err)
+			log.Println(err)
+			return newFailureEvent(createIncIssueFailureEventDef, input, err)
+		}
+		// also for duplicates, in case linking failed when the issue was created
+		if err := c.LinkIncident(issue.Key, handlerInput.Inc); err != nil {
+			err = fmt.Errorf("issue %s was created but could not be linked to the incident: %w", issue.Key, err)
+			log.Println(err)
+			return newFailureEvent(createIncIssueFailureEventDef, input, err)
+		}
 
-	return flyte.Event{
-		EventDef: createIncIssueEventDef,
-		Payload: CreateIncIssueSuccess{
-			ID:   issue.ID,
-			Key:  issue.Key,
-			Self: issue.Self,
-		},
+		return flyte.Event{
+			EventDef: createIncIssueEventDef,
+			Payload: CreateIncIssueSuccess{
+				ID:        issue.ID,
+				Key:       issue.Key,
+				Self:      issue.Self,
+				Incident:  handlerInput.Inc,
+				Duplicate: duplicate,
+			},
+		}
 	}
 }
 
@@ -121,66 +203,67 @@ var createIssueEventDef = flyte.EventDef{
 }
 
 type createIssueSuccessPayload struct {
-	Id          string `json:"id"`
-	Url         string `json:"url"`
-	Project     string `json:"project"`
-	IssueType   string `json:"issuetype"`
-	Summary     string `json:"summary"`
-	Description string `json:"description"`
-	Priority    string `json:"priority"`
-	Reporter    string `json:"reporter"`
+	Id              string   `json:"id"`
+	Url             string   `json:"url"`
+	Project         string   `json:"project"`
+	IssueType       string   `json:"issuetype"`
+	Summary         string   `json:"summary"`
+	Description     string   `json:"description"`
+	Priority        string   `json:"priority"`
+	Reporter        string   `json:"reporter"`
+	Assignee        string   `json:"assignee,omitempty"`
+	Labels          []string `json:"labels,omitempty"`
+	Components      []string `json:"components,omitempty"`
+	FixVersions     []string `json:"fixVersions,omitempty"`
+	AffectsVersions []string `json:"affectsVersions,omitempty"`
+	DueDate         string   `json:"duedate,omitempty"`
+	Environment     string   `json:"environment,omitempty"`
+	Parent          string   `json:"parent,omitempty"`
+	EpicLink        string   `json:"epicLink,omitempty"`
+	DedupeKey       string   `json:"dedupeKey,omitempty"`
+	// Duplicate tells that the issue was not created, because it was
+	// created for DedupeKey already.
+	Duplicate bool `json:"duplicate,omitempty"`
 }
 
 var createIssueFailureEventDef = flyte.EventDef{
 	Name: "CreateIssueFailure",
 }
 
-type createIssueFailurePayload struct {
-	Error       string `json:"error"`
-	Project     string `json:"project"`
-	IssueType   string `json:"issuetype"`
-	Summary     string `json:"summary"`
-	Description string `json:"description"`
-	Priority    string `json:"priority"`
-}
-
-func newCreateIssueFailureEvent(err, project, issueType, summary string) flyte.Event {
-	return flyte.Event{
-		EventDef: createIssueFailureEventDef,
-		Payload: createIssueFailurePayload{
-			Error:     err,
-			Project:   project,
-			IssueType: issueType,
-			Summary:   summary,
-		},
-	}
-}
-
-func newCreateIssueEvent(url, id, project, issueType, summary string, description string, priority string, reporter string) flyte.Event {
+func newCreateIssueEvent(url, id string, input Input, duplicate bool) flyte.Event {
 	return flyte.Event{
 		EventDef: createIssueEventDef,
 		Payload: createIssueSuccessPayload{
-			Id:          id,
-			Url:         url,
-			Project:     project,
-			IssueType:   issueType,
-			Summary:     summary,
-			Description: description,
-			Priority:    priority,
-			Reporter:    reporter,
+			Id:              id,
+			Url:             url,
+			Project:         input.Project,
+			IssueType:       input.IssueType,
+			Summary:         input.Summary,
+			Description:     input.Description,
+			Priority:        input.Priority,
+			Reporter:        input.Reporter,
+			Assignee:        input.Assignee,
+			Labels:          input.Labels,
+			Components:      input.Components,
+			FixVersions:     input.FixVersions,
+			AffectsVersions: input.AffectsVersions,
+			DueDate:         input.DueDate,
+			Environment:     input.Environment,
+			Parent:          input.Parent,
+			EpicLink:        input.EpicLink,
+			DedupeKey:       input.DedupeKey,
+			Duplicate:       duplicate,
 		},
 	}
 }
 
 // Types and functions for createIncIssue command
 type CreateIncIssueSuccess struct { // to be returned into slack
-	ID   string `json:"id"`
-	Key  string `json:"key"`
-	Self string `json:"self"`
-}
-
-type CreateIncIssueFailure struct { // to be returned into slack
-	Message string `json:"message"`
+	ID        string `json:"id"`
+	Key       string `json:"key"`
+	Self      string `json:"self"`
+	Incident  string `json:"incident"`
+	Duplicate bool   `json:"duplicate,omitempty"`
 }
 
 var createIncIssueEventDef = flyte.EventDef{
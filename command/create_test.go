@@ -17,52 +17,306 @@ limitations under the License.
 package command
 
 import (
+	"encoding/json"
 	"github.com/ExpediaGroup/flyte-client/flyte"
-	"github.com/ExpediaGroup/flyte-jira/client"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestCreateIssueAsExpected(t *testing.T) {
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusCreated, nil
-	}
+	c := newTestClient(t, respondWith(http.StatusCreated, struct{}{}))
 	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story","description": "test description", "priority": "Medium", "reporter": "songupta"}`)
-	actualEvent := createIssueHandler(input)
-	expectedEvent := newCreateIssueEvent("/browse/", "", "FLYTE", "Story", "test story", "test description", "Medium", "songupta")
+	actualEvent := createIssueHandler(singleInstanceRouter(t, c))(input)
+	expectedEvent := newCreateIssueEvent(c.Host()+"/browse/", "", Input{Project: "FLYTE", IssueType: "Story", Summary: "test story", Description: "test description", Priority: "Medium", Reporter: "songupta"}, false)
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
 	}
 }
 
-func TestCreateIssueFailure(t *testing.T) {
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusBadRequest, nil
+func TestCreateIssueWithStandardFields(t *testing.T) {
+	var fields map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fields = body.Fields
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"key": "FLYTE-2"}`))
+	})
+	input := []byte(`{"project": "FLYTE", "issuetype": "Bug", "summary": "checkout is down", "assignee": "jdoe",
+		"labels": ["incident"], "components": ["checkout"], "fixVersions": ["2.1"], "affectsVersions": ["2.0"],
+		"duedate": "2026-11-01", "environment": "production", "parent": "FLYTE-1"}`)
+
+	actualEvent := createIssueHandler(singleInstanceRouter(t, c))(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: createIssueEventDef,
+		Payload: createIssueSuccessPayload{
+			Id:              "FLYTE-2",
+			Url:             c.Host() + "/browse/FLYTE-2",
+			Project:         "FLYTE",
+			IssueType:       "Bug",
+			Summary:         "checkout is down",
+			Assignee:        "jdoe",
+			Labels:          []string{"incident"},
+			Components:      []string{"checkout"},
+			FixVersions:     []string{"2.1"},
+			AffectsVersions: []string{"2.0"},
+			DueDate:         "2026-11-01",
+			Environment:     "production",
+			Parent:          "FLYTE-1",
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	expectedFields := map[string]interface{}{
+		"project":     map[string]interface{}{"key": "FLYTE"},
+		"issuetype":   map[string]interface{}{"name": "Bug"},
+		"summary":     "checkout is down",
+		"description": "",
+		"reporter":    map[string]interface{}{"name": ""},
+		"assignee":    map[string]interface{}{"name": "jdoe"},
+		"labels":      []interface{}{"incident"},
+		"components":  []interface{}{map[string]interface{}{"name": "checkout"}},
+		"fixVersions": []interface{}{map[string]interface{}{"name": "2.1"}},
+		"versions":    []interface{}{map[string]interface{}{"name": "2.0"}},
+		"duedate":     "2026-11-01",
+		"environment": "production",
+		"parent":      map[string]interface{}{"key": "FLYTE-1"},
 	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("Expected fields: %v but got: %v", expectedFields, fields)
+	}
+}
+
+func TestCreateIssueFailure(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusBadRequest, struct{}{}))
 	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story"}`)
-	actualEvent := createIssueHandler(input)
-	expectedEvent := newCreateIssueFailureEvent("Could not create issue: issueSummary='test story' : statusCode=400", "FLYTE", "Story", "test story")
+	actualEvent := createIssueHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: createIssueFailureEventDef,
+		Payload: failurePayload{
+			Code:           "BAD_REQUEST",
+			Error:          "Could not create issue: issueSummary='test story' : statusCode=400",
+			failureDetails: failureDetails{StatusCode: 400},
+			Input:          json.RawMessage(input),
+		},
+	}
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
 	}
 }
 
+func TestCreateIssueFailureReportsFieldErrors(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusBadRequest, map[string]interface{}{
+		"errorMessages": []string{},
+		"errors":        map[string]string{"reporter": "The reporter specified is not a user."},
+	}))
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "reporter": "nobody"}`)
+
+	payload := createIssueHandler(r)(input).Payload.(failurePayload)
+
+	expectedDetails := failureDetails{
+		StatusCode:  400,
+		FieldErrors: map[string]string{"reporter": "The reporter specified is not a user."},
+	}
+	if !reflect.DeepEqual(payload.failureDetails, expectedDetails) {
+		t.Errorf("Expected: %+v but got: %+v", expectedDetails, payload.failureDetails)
+	}
+}
+
 func TestCreateCustomIssueAsExpected(t *testing.T) {
-	client.SendCustomRequest = func(request *http.Request) ([]byte, error) {
-		return []byte(`{}`), nil
+	r := newTestRouter(t, respondWith(http.StatusCreated, struct{}{}))
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "incident":"INC1234567"}`)
+	actualEvent := createIncIssueHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: createIncIssueEventDef,
+		Payload: CreateIncIssueSuccess{
+			ID:       "",
+			Key:      "",
+			Self:     "",
+			Incident: "INC1234567",
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestCreateCustomIssueFailure(t *testing.T) {
+	forbidden := respondWith(http.StatusForbidden, map[string]interface{}{
+		"errorMessages": []string{"You do not have permission to create issues in this project."},
+	})
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/search" {
+			w.Write([]byte(`{"issues": []}`))
+			return
+		}
+		forbidden(w, r)
+	})
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "incident":"INC1234567"}`)
+	actualEvent := createIncIssueHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: createIncIssueFailureEventDef,
+		Payload: failurePayload{
+			Code:  "FORBIDDEN",
+			Error: "could not create issue: issueSummary='test story' : statusCode=403 : You do not have permission to create issues in this project.",
+			failureDetails: failureDetails{
+				StatusCode:    403,
+				ErrorMessages: []string{"You do not have permission to create issues in this project."},
+			},
+			Input: json.RawMessage(input),
+		},
 	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestCreateCustomIssueInvalidIncident(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusCreated, struct{}{}))
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "incident":"INC12"}`)
+	actualEvent := createIncIssueHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: createIncIssueFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "INC12: invalid incident number format",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestCreateIssueUnknownCustomField(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/field" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"id": "customfield_10002", "name": "Story Points", "schema": {"type": "number"}}]`))
+	})
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "customFields": {"Story Pts": 3}}`)
+
+	payload := createIssueHandler(r)(input).Payload.(failurePayload)
+
+	if payload.Code != "INVALID_INPUT" || payload.Error != `Could not create issue: issueSummary='test story' : invalid custom field "Story Pts": no such field` {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
+
+func TestCreateIssueFailsPreFlightValidation(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/issue/createmeta/FLYTE/issuetypes":
+			w.Write([]byte(`{"values": [{"id": "2", "name": "Story"}], "isLast": true}`))
+		case "/rest/api/2/issue/createmeta/FLYTE/issuetypes/2":
+			w.Write([]byte(`{"values": [
+				{"fieldId": "summary", "name": "Summary", "required": true},
+				{"fieldId": "description", "name": "Description", "required": true},
+				{"fieldId": "priority", "name": "Priority", "allowedValues": [{"id": "1", "name": "High"}, {"id": "3", "name": "Low"}]}
+			], "isLast": true}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "priority": "Urgent"}`)
+
+	payload := createIssueHandler(r)(input).Payload.(failurePayload)
+
+	expectedFieldErrors := map[string]string{
+		"description": "Description is required",
+		"priority":    `"Urgent" is not an allowed value for Priority, use one of: High, Low`,
+	}
+	if payload.Code != "INVALID_INPUT" || !reflect.DeepEqual(payload.FieldErrors, expectedFieldErrors) {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
+
+func TestCreateIncIssueSummaryTooLong(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			w.Write([]byte(`{"issues": []}`))
+		case "/rest/api/2/issue/":
+			t.Errorf("issue should not be created")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	input, _ := json.Marshal(map[string]string{"project": "FLYTE", "issuetype": "Story", "summary": strings.Repeat("x", 256), "incident": "INC1234567"})
+
+	payload := createIncIssueHandler(r)(input).Payload.(failurePayload)
+
+	expectedFieldErrors := map[string]string{"summary": "must be at most 255 characters long, it has 256"}
+	if payload.Code != "INVALID_INPUT" || !reflect.DeepEqual(payload.FieldErrors, expectedFieldErrors) {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
+
+func TestCreateIncIssueReturnsDuplicate(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/search" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		w.Write([]byte(`{"issues": [{"id": "10001", "key": "FLYTE-1", "self": "https://jira/rest/api/2/issue/10001"}]}`))
+	})
 	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "incident":"INC1234567"}`)
-	actualEvent := createIncIssueHandler(input)
+
+	actualEvent := createIncIssueHandler(r)(input)
+
 	expectedEvent := flyte.Event{
 		EventDef: createIncIssueEventDef,
 		Payload: CreateIncIssueSuccess{
-			ID:   "",
-			Key:  "",
-			Self: "",
+			ID:        "10001",
+			Key:       "FLYTE-1",
+			Self:      "https://jira/rest/api/2/issue/10001",
+			Incident:  "INC1234567",
+			Duplicate: true,
 		},
 	}
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
 	}
 }
+
+func TestCreateIssueWithDedupeKey(t *testing.T) {
+	var labels []interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			w.Write([]byte(`{"issues": []}`))
+		case "/rest/api/2/issue/":
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			labels, _ = body.Fields["labels"].([]interface{})
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "labels": ["postmortem"], "dedupeKey": "PM-42-action-1"}`)
+
+	payload := createIssueHandler(singleInstanceRouter(t, c))(input).Payload.(createIssueSuccessPayload)
+
+	if payload.Id != "FLYTE-2" || payload.Duplicate || payload.DedupeKey != "PM-42-action-1" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	expectedLabels := []interface{}{"postmortem", "flyte-dedupe-PM-42-action-1"}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("Expected labels: %v but got: %v", expectedLabels, labels)
+	}
+}
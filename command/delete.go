@@ -0,0 +1,133 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+)
+
+var (
+	issueDeletedEventDef = flyte.EventDef{
+		Name: "IssueDeleted",
+	}
+
+	issueDeleteFailureEventDef = flyte.EventDef{
+		Name: "IssueDeleteFailure",
+	}
+
+	issueArchivedEventDef = flyte.EventDef{
+		Name: "IssueArchived",
+	}
+
+	issueArchiveFailureEventDef = flyte.EventDef{
+		Name: "IssueArchiveFailure",
+	}
+)
+
+func DeleteIssueCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "DeleteIssue",
+		OutputEvents: []flyte.EventDef{issueDeletedEventDef, issueDeleteFailureEventDef},
+		Handler:      deleteIssueHandler(r),
+	}
+}
+
+func ArchiveIssueCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "ArchiveIssue",
+		OutputEvents: []flyte.EventDef{issueArchivedEventDef, issueArchiveFailureEventDef},
+		Handler:      archiveIssueHandler(r),
+	}
+}
+
+type (
+	removeIssueRequest struct {
+		IssueId        string `json:"issueId"`
+		DeleteSubtasks bool   `json:"deleteSubtasks"`
+		instanceSelector
+	}
+
+	issueRemovedPayload struct {
+		IssueId        string `json:"issueId"`
+		DeleteSubtasks bool   `json:"deleteSubtasks,omitempty"`
+	}
+)
+
+func deleteIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := removeIssueRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Delete Issue Request [%s]: %s", input, err)
+			return newFailureEvent(issueDeleteFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" {
+			return newFailureEvent(issueDeleteFailureEventDef, input, invalidInput(errors.New("issueId is required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Delete Issue Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(issueDeleteFailureEventDef, input, err)
+		}
+
+		if err := c.DeleteIssue(req.IssueId, req.DeleteSubtasks); err != nil {
+			err = fmt.Errorf("Could not delete issue: %w", err)
+			log.Println(err)
+			return newFailureEvent(issueDeleteFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: issueDeletedEventDef,
+			Payload:  issueRemovedPayload{IssueId: req.IssueId, DeleteSubtasks: req.DeleteSubtasks},
+		}
+	}
+}
+
+func archiveIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := removeIssueRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Archive Issue Request [%s]: %s", input, err)
+			return newFailureEvent(issueArchiveFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" {
+			return newFailureEvent(issueArchiveFailureEventDef, input, invalidInput(errors.New("issueId is required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Archive Issue Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(issueArchiveFailureEventDef, input, err)
+		}
+
+		if err := c.ArchiveIssue(req.IssueId); err != nil {
+			err = fmt.Errorf("Could not archive issue: %w", err)
+			log.Println(err)
+			return newFailureEvent(issueArchiveFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: issueArchivedEventDef,
+			Payload:  issueRemovedPayload{IssueId: req.IssueId},
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// deletionRouter routes to a Jira stand-in holding FLYTE-1, which issues of
+// the projects may be deleted from.
+func deletionRouter(t *testing.T, projects ...string) (*client.Router, *int) {
+	removals := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/FLYTE-1":
+			w.Write([]byte(`{"key": "FLYTE-1", "fields": {"project": {"key": "FLYTE"}}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/rest/api/2/issue/FLYTE-1":
+			removals++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	c, err := client.New(client.Config{Host: server.URL, Deletion: client.DeletionConfig{Projects: projects}})
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	return singleInstanceRouter(t, c), &removals
+}
+
+func TestDeleteIssueAsExpected(t *testing.T) {
+	r, removals := deletionRouter(t, "FLYTE")
+
+	actualEvent := deleteIssueHandler(r)([]byte(`{"issueId": "FLYTE-1", "deleteSubtasks": true}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: issueDeletedEventDef,
+		Payload:  issueRemovedPayload{IssueId: "FLYTE-1", DeleteSubtasks: true},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	if *removals != 1 {
+		t.Errorf("Expected the issue to be deleted once but got %d deletions", *removals)
+	}
+}
+
+func TestDeleteIssueNotAllowed(t *testing.T) {
+	r, removals := deletionRouter(t, "OPS")
+	input := []byte(`{"issueId": "FLYTE-1"}`)
+
+	actualEvent := deleteIssueHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: issueDeleteFailureEventDef,
+		Payload: failurePayload{
+			Code:  "NOT_ALLOWED",
+			Error: "Could not delete issue: issueId=FLYTE-1 : not allowed: project FLYTE is not one of the projects issues may be deleted from",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+	if *removals != 0 {
+		t.Errorf("Expected no deletion but got %d", *removals)
+	}
+}
+
+func TestArchiveIssueNotSupported(t *testing.T) {
+	r, _ := deletionRouter(t, "FLYTE")
+	input := []byte(`{"issueId": "FLYTE-1"}`)
+
+	actualEvent := archiveIssueHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: issueArchiveFailureEventDef,
+		Payload: failurePayload{
+			Code:  "NOT_SUPPORTED",
+			Error: "Could not archive issue: issueId=FLYTE-1 : archiving issues is not supported by this jira instance : statusCode=404",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestDeleteIssueWithoutIssueId(t *testing.T) {
+	r, _ := deletionRouter(t, "FLYTE")
+	input := []byte(`{"deleteSubtasks": true}`)
+
+	actualEvent := deleteIssueHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: issueDeleteFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "issueId is required",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
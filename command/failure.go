@@ -0,0 +1,178 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"net/http"
+	"net/url"
+)
+
+// Codes classifying why a command failed, so flows can react to failures of
+// any command the same way.
+const (
+	codeInvalidInput    = "INVALID_INPUT"
+	codeUnknownInstance = "UNKNOWN_INSTANCE"
+	codeRateLimited     = "RATE_LIMITED"
+	codeNotAllowed      = "NOT_ALLOWED"
+	codeNotSupported    = "NOT_SUPPORTED"
+	codeConnectionError = "CONNECTION_ERROR"
+	codeBadRequest      = "BAD_REQUEST"
+	codeUnauthorized    = "UNAUTHORIZED"
+	codeForbidden       = "FORBIDDEN"
+	codeNotFound        = "NOT_FOUND"
+	codeConflict        = "CONFLICT"
+	codeThrottled       = "THROTTLED"
+	codeJiraUnavailable = "JIRA_UNAVAILABLE"
+	codeJiraError       = "JIRA_ERROR"
+	codeUnexpectedError = "UNEXPECTED_ERROR"
+)
+
+// failurePayload is the payload of the failure event of every command.
+type failurePayload struct {
+	Code string `json:"code"`
+	// Error is the human readable description of the failure.
+	Error string `json:"error"`
+	// Retryable tells whether sending the same command again later may
+	// succeed, e.g. because Jira was throttling or unavailable.
+	Retryable bool `json:"retryable"`
+	failureDetails
+	// Input echoes the input of the failed command.
+	Input interface{} `json:"input"`
+}
+
+// failureDetails passes on what Jira said about a refused request, and how
+// often the failed call was retried before the pack gave up, and why.
+type failureDetails struct {
+	StatusCode    int               `json:"statusCode,omitempty"`
+	ErrorMessages []string          `json:"errorMessages,omitempty"`
+	FieldErrors   map[string]string `json:"fieldErrors,omitempty"`
+	RequestID     string            `json:"requestId,omitempty"`
+	Retries       int               `json:"retries,omitempty"`
+	RetryReason   string            `json:"retryReason,omitempty"`
+}
+
+// inputError marks a failure caused by the command input rather than by Jira.
+type inputError struct {
+	err error
+}
+
+func (e *inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+func invalidInput(err error) error {
+	return &inputError{err: err}
+}
+
+func newFailureEvent(eventDef flyte.EventDef, input json.RawMessage, err error) flyte.Event {
+	code, retryable := classifyFailure(err)
+	return flyte.Event{
+		EventDef: eventDef,
+		Payload: failurePayload{
+			Code:           code,
+			Error:          err.Error(),
+			Retryable:      retryable,
+			failureDetails: newFailureDetails(err),
+			Input:          echoInput(input),
+		},
+	}
+}
+
+// echoInput returns input as is when it is valid JSON, otherwise as a string
+// so that the failure event can still be sent.
+func echoInput(input json.RawMessage) interface{} {
+	if len(input) == 0 {
+		return nil
+	}
+	if json.Valid(input) {
+		return input
+	}
+	return string(input)
+}
+
+func classifyFailure(err error) (code string, retryable bool) {
+	var inputErr *inputError
+	var validationErr *client.ValidationError
+	var jiraErr *client.JiraError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &inputErr), errors.As(err, &validationErr), errors.Is(err, client.ErrInvalidField), errors.Is(err, client.ErrUserNotFound):
+		return codeInvalidInput, false
+	case errors.Is(err, client.ErrUnknownInstance):
+		return codeUnknownInstance, false
+	case errors.Is(err, client.ErrRateLimited):
+		return codeRateLimited, true
+	case errors.Is(err, client.ErrNotAllowed):
+		return codeNotAllowed, false
+	case errors.Is(err, client.ErrNotSupported):
+		return codeNotSupported, false
+	case errors.As(err, &jiraErr):
+		return classifyStatus(jiraErr.StatusCode)
+	case errors.As(err, &urlErr):
+		return codeConnectionError, true
+	}
+	return codeUnexpectedError, false
+}
+
+func classifyStatus(status int) (code string, retryable bool) {
+	switch status {
+	case http.StatusBadRequest:
+		return codeBadRequest, false
+	case http.StatusUnauthorized:
+		return codeUnauthorized, false
+	case http.StatusForbidden:
+		return codeForbidden, false
+	case http.StatusNotFound:
+		return codeNotFound, false
+	case http.StatusConflict:
+		return codeConflict, false
+	case http.StatusTooManyRequests:
+		return codeThrottled, true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codeJiraUnavailable, true
+	}
+	return codeJiraError, status >= 500
+}
+
+func newFailureDetails(err error) failureDetails {
+	var details failureDetails
+	var jiraErr *client.JiraError
+	if errors.As(err, &jiraErr) {
+		details.StatusCode = jiraErr.StatusCode
+		details.ErrorMessages = jiraErr.Messages
+		details.FieldErrors = jiraErr.FieldErrors
+		details.RequestID = jiraErr.RequestID
+	}
+	var validationErr *client.ValidationError
+	if errors.As(err, &validationErr) {
+		details.FieldErrors = validationErr.FieldErrors
+	}
+	var retryErr *client.RetryError
+	if errors.As(err, &retryErr) {
+		details.Retries = retryErr.Retries
+		details.RetryReason = retryErr.Reason
+	}
+	return details
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		err       error
+		code      string
+		retryable bool
+	}{
+		{invalidInput(errors.New("Empty query string")), "INVALID_INPUT", false},
+		{fmt.Errorf("%w %q", client.ErrUnknownInstance, "staging"), "UNKNOWN_INSTANCE", false},
+		{fmt.Errorf("issueId=A-1 : %w", client.ErrRateLimited), "RATE_LIMITED", true},
+		{fmt.Errorf("issueId=A-1 : %w: project A is not one of the projects issues may be deleted from", client.ErrNotAllowed), "NOT_ALLOWED", false},
+		{fmt.Errorf("issueId=A-1 : archiving issues is %w : %v", client.ErrNotSupported, &client.JiraError{StatusCode: 404}), "NOT_SUPPORTED", false},
+		{&client.JiraError{StatusCode: 400}, "BAD_REQUEST", false},
+		{&client.JiraError{StatusCode: 401}, "UNAUTHORIZED", false},
+		{&client.JiraError{StatusCode: 403}, "FORBIDDEN", false},
+		{&client.JiraError{StatusCode: 404}, "NOT_FOUND", false},
+		{&client.JiraError{StatusCode: 409}, "CONFLICT", false},
+		{&client.JiraError{StatusCode: 422}, "JIRA_ERROR", false},
+		{&client.RetryError{Retries: 3, Err: &client.JiraError{StatusCode: 429}}, "THROTTLED", true},
+		{&client.JiraError{StatusCode: 503}, "JIRA_UNAVAILABLE", true},
+		{&client.JiraError{StatusCode: 500}, "JIRA_ERROR", true},
+		{&url.Error{Op: "Get", URL: "http://jira.test", Err: errors.New("connection refused")}, "CONNECTION_ERROR", true},
+		{errors.New("cannot get oauth2 access token"), "UNEXPECTED_ERROR", false},
+	}
+
+	for _, test := range tests {
+		code, retryable := classifyFailure(test.err)
+		assert.Equal(t, test.code, code, test.err.Error())
+		assert.Equal(t, test.retryable, retryable, test.err.Error())
+	}
+}
+
+func TestFailurePayloadJSON(t *testing.T) {
+	err := &client.RetryError{Retries: 2, Reason: "503 Service Unavailable", Err: &client.JiraError{StatusCode: 503, RequestID: "42"}}
+	event := newFailureEvent(infoFailureEventDef, json.RawMessage(`{"id":"OPS-1"}`), fmt.Errorf("issueId=OPS-1 : %w", err))
+
+	b, marshalErr := json.Marshal(event.Payload)
+
+	assert.NoError(t, marshalErr)
+	assert.JSONEq(t, `{
+		"code": "JIRA_UNAVAILABLE",
+		"error": "issueId=OPS-1 : statusCode=503 (requestId=42) (gave up after 2 retries: 503 Service Unavailable)",
+		"retryable": true,
+		"statusCode": 503,
+		"requestId": "42",
+		"retries": 2,
+		"retryReason": "503 Service Unavailable",
+		"input": {"id": "OPS-1"}
+	}`, string(b))
+}
+
+func TestFailurePayloadEchoesInvalidInput(t *testing.T) {
+	event := newFailureEvent(infoFailureEventDef, json.RawMessage(`{"id":`), invalidInput(errors.New("unexpected end of JSON input")))
+
+	b, err := json.Marshal(event.Payload)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"code": "INVALID_INPUT", "error": "unexpected end of JSON input", "retryable": false, "input": "{\"id\":"}`, string(b))
+}
@@ -8,12 +8,6 @@ import (
 )
 
 var (
-	GetTransitions = flyte.Command{
-		Name:         "GetTransitions",
-		OutputEvents: []flyte.EventDef{getTransitionsEventDef, getTransitionsFailureEventDef},
-		Handler:      getTransitionsHandler,
-	}
-
 	getTransitionsEventDef = flyte.EventDef{
 		Name: "GetTransitions",
 	}
@@ -23,8 +17,17 @@ var (
 	}
 )
 
+func GetTransitions(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "GetTransitions",
+		OutputEvents: []flyte.EventDef{getTransitionsEventDef, getTransitionsFailureEventDef},
+		Handler:      getTransitionsHandler(r),
+	}
+}
+
 type issueId struct {
 	IssueId string `json:"issueId"`
+	instanceSelector
 }
 
 type transitionsSuccessPayload struct {
@@ -32,37 +35,29 @@ type transitionsSuccessPayload struct {
 	Results []client.TransitionObj `json:"transitions"`
 }
 
-type transitionsFailurePayload struct {
-	Id    string `json:"id"`
-	Error string `json:"error"`
-}
-
-func getTransitionsHandler(input json.RawMessage) flyte.Event {
-	req := issueId{}
-	if err := json.Unmarshal(input, &req); err != nil {
-		log.Printf("Error unmarshaling getting transitions request [%s]: %s", input, err)
-		return getTransitionsFailureEvent(req, err)
-	}
-	results, err := client.GetTransitions(req.IssueId)
-	if err != nil {
-		log.Printf("Error getting transitions for issue %s: %s", req.IssueId, err)
-		return getTransitionsFailureEvent(req, err)
-	}
-	return flyte.Event{
-		EventDef: getTransitionsEventDef,
-		Payload: transitionsSuccessPayload{
-			Id:      req.IssueId,
-			Results: results.Transitions,
-		},
-	}
-}
-
-func getTransitionsFailureEvent(request issueId, err error) flyte.Event {
-	return flyte.Event{
-		EventDef: getTransitionsFailureEventDef,
-		Payload: transitionsFailurePayload{
-			request.IssueId,
-			err.Error(),
-		},
+func getTransitionsHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := issueId{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling getting transitions request [%s]: %s", input, err)
+			return newFailureEvent(getTransitionsFailureEventDef, input, invalidInput(err))
+		}
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing getting transitions request for issue %s: %s", req.IssueId, err)
+			return newFailureEvent(getTransitionsFailureEventDef, input, err)
+		}
+		results, err := c.GetTransitions(req.IssueId)
+		if err != nil {
+			log.Printf("Error getting transitions for issue %s: %s", req.IssueId, err)
+			return newFailureEvent(getTransitionsFailureEventDef, input, err)
+		}
+		return flyte.Event{
+			EventDef: getTransitionsEventDef,
+			Payload: transitionsSuccessPayload{
+				Id:      req.IssueId,
+				Results: results.Transitions,
+			},
+		}
 	}
 }
@@ -14,7 +14,7 @@ limitations under the License.
 package command
 
 import (
-	"errors"
+	"encoding/json"
 	"github.com/ExpediaGroup/flyte-client/flyte"
 	"github.com/ExpediaGroup/flyte-jira/client"
 	"github.com/stretchr/testify/assert"
@@ -24,18 +24,17 @@ import (
 )
 
 func TestGetTransitionsWorkingAsExpected(t *testing.T) {
-	initialFunc := client.SendRequest
-	defer func() { client.SendRequest = initialFunc }()
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
+	r := newTestRouter(t, func(w http.ResponseWriter, request *http.Request) {
 		path := request.URL.Path
 		var subStr = strings.Split(path, "/")
 		if subStr[5] != "DEVEX-567" {
-			return http.StatusNotFound, errors.New("requested jira issue not found")
+			w.WriteHeader(http.StatusNotFound)
+			return
 		}
-		return http.StatusOK, nil
-	}
+		w.Write([]byte(`{"transitions":[]}`))
+	})
 	input := []byte(`{"issueId":"DEVEX-567"}`)
-	actualEvent := getTransitionsHandler(input)
+	actualEvent := getTransitionsHandler(r)(input)
 	expectedEvent := flyte.Event{
 		EventDef: getTransitionsEventDef,
 		Payload: transitionsSuccessPayload{
@@ -47,23 +46,25 @@ func TestGetTransitionsWorkingAsExpected(t *testing.T) {
 }
 
 func TestGetTransitionsFailure(t *testing.T) {
-	initialFunc := client.SendRequest
-	defer func() { client.SendRequest = initialFunc }()
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
+	r := newTestRouter(t, func(w http.ResponseWriter, request *http.Request) {
 		reqPath := request.URL.Path
 		expReqPath := "/rest/api/2/issue/DEVEX-567/transitions"
 		if reqPath != expReqPath {
-			return http.StatusNotFound, errors.New("Issue does not exist")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+			return
 		}
-		return http.StatusOK, nil
-	}
+		w.Write([]byte(`{"transitions":[]}`))
+	})
 	input := []byte(`{"issueId":"DEVEX-5677777"}`)
-	actualEvent := getTransitionsHandler(input)
+	actualEvent := getTransitionsHandler(r)(input)
 	expectedEvent := flyte.Event{
 		EventDef: getTransitionsFailureEventDef,
-		Payload: transitionsFailurePayload{
-			Id:    "DEVEX-5677777",
-			Error: "Issue does not exist",
+		Payload: failurePayload{
+			Code:           "NOT_FOUND",
+			Error:          "issueId=DEVEX-5677777 : statusCode=404",
+			failureDetails: failureDetails{StatusCode: 404},
+			Input:          json.RawMessage(input),
 		},
 	}
 	assert.Equal(t, expectedEvent, actualEvent)
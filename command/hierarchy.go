@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+)
+
+var (
+	createSubtaskEventDef = flyte.EventDef{
+		Name: "CreateSubtask",
+	}
+
+	createSubtaskFailureEventDef = flyte.EventDef{
+		Name: "CreateSubtaskFailure",
+	}
+
+	childIssuesEventDef = flyte.EventDef{
+		Name: "ChildIssues",
+	}
+
+	childIssuesFailureEventDef = flyte.EventDef{
+		Name: "ChildIssuesFailure",
+	}
+)
+
+func CreateSubtaskCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "CreateSubtask",
+		OutputEvents: []flyte.EventDef{createSubtaskEventDef, createSubtaskFailureEventDef},
+		Handler:      createSubtaskHandler(r),
+	}
+}
+
+func GetChildIssuesCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "GetChildIssues",
+		OutputEvents: []flyte.EventDef{childIssuesEventDef, childIssuesFailureEventDef},
+		Handler:      getChildIssuesHandler(r),
+	}
+}
+
+type (
+	childIssuesRequest struct {
+		IssueId    string `json:"issueId"`
+		StartIndex int    `json:"startIndex"`
+		MaxResults int    `json:"maxResults"`
+		instanceSelector
+	}
+
+	childIssuesPayload struct {
+		IssueId      string         `json:"issueId"`
+		StartIndex   int            `json:"startIndex"`
+		MaxResults   int            `json:"maxResults"`
+		TotalResults int            `json:"total"`
+		Issues       []IssuePayload `json:"issues"`
+	}
+)
+
+// createSubtaskHandler takes the same input as CreateIssue, with parent being
+// required and project and issuetype defaulting to the ones of the parent's
+// project. The event reports the project and issue type actually used.
+func createSubtaskHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		handlerInput := Input{}
+		if err := json.Unmarshal(input, &handlerInput); err != nil {
+			log.Printf("Error unmarshaling Create Subtask Request [%s]: %s", input, err)
+			return newFailureEvent(createSubtaskFailureEventDef, input, invalidInput(err))
+		}
+		if handlerInput.Parent == "" {
+			return newFailureEvent(createSubtaskFailureEventDef, input, invalidInput(errors.New("parent is required")))
+		}
+
+		c, err := r.Route(handlerInput.Instance, handlerInput.Parent)
+		if err != nil {
+			log.Printf("Error routing Create Subtask Request for %s: %s", handlerInput.Parent, err)
+			return newFailureEvent(createSubtaskFailureEventDef, input, err)
+		}
+
+		subtask, err := c.NewSubtask(handlerInput.Parent, handlerInput.newIssue())
+		if err != nil {
+			err = fmt.Errorf("Could not create sub-task: %w", err)
+			log.Println(err)
+			return newFailureEvent(createSubtaskFailureEventDef, input, err)
+		}
+		handlerInput.Project, handlerInput.IssueType = subtask.Project, subtask.IssueType
+
+		issue, duplicate, err := createDeduplicated(c, handlerInput.Project, handlerInput.DedupeKey, func(labels []string) (client.CreateIssueAPIResponse, error) {
+			subtask.Labels = append(subtask.Labels, labels...)
+			issue, err := c.CreateIssue(subtask)
+			return client.CreateIssueAPIResponse{ID: issue.ID, Key: issue.Key, Self: issue.Self}, err
+		})
+		if err != nil {
+			err = fmt.Errorf("Could not create sub-task: %w", err)
+			log.Println(err)
+			return newFailureEvent(createSubtaskFailureEventDef, input, err)
+		}
+
+		event := newCreateIssueEvent(fmt.Sprintf("%s/browse/%s", c.BrowseURL(), issue.Key), issue.Key, handlerInput, duplicate)
+		event.EventDef = createSubtaskEventDef
+		return event
+	}
+}
+
+func getChildIssuesHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := childIssuesRequest{MaxResults: 50}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Get Child Issues Request [%s]: %s", input, err)
+			return newFailureEvent(childIssuesFailureEventDef, input, invalidInput(err))
+		}
+		if req.IssueId == "" {
+			return newFailureEvent(childIssuesFailureEventDef, input, invalidInput(errors.New("issueId is required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Get Child Issues Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(childIssuesFailureEventDef, input, err)
+		}
+
+		result, err := c.GetChildIssues(req.IssueId, req.StartIndex, req.MaxResults)
+		if err != nil {
+			err = fmt.Errorf("Could not get child issues: %w", err)
+			log.Println(err)
+			return newFailureEvent(childIssuesFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: childIssuesEventDef,
+			Payload: childIssuesPayload{
+				IssueId:      req.IssueId,
+				StartIndex:   req.StartIndex,
+				MaxResults:   req.MaxResults,
+				TotalResults: result.TotalResults,
+				Issues:       newIssuePayloads(result.Issues),
+			},
+		}
+	}
+}
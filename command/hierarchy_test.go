@@ -0,0 +1,122 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCreateSubtaskAsExpected(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/issue/createmeta/FLYTE/issuetypes":
+			w.Write([]byte(`{"values": [{"id": "5", "name": "Sub-task", "subtask": true}], "isLast": true}`))
+		case "/rest/api/2/issue/":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "FLYTE-2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	input := []byte(`{"parent": "FLYTE-1", "summary": "roll back the deployment", "assignee": "jdoe"}`)
+
+	actualEvent := createSubtaskHandler(singleInstanceRouter(t, c))(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: createSubtaskEventDef,
+		Payload: createIssueSuccessPayload{
+			Id:        "FLYTE-2",
+			Url:       c.Host() + "/browse/FLYTE-2",
+			Project:   "FLYTE",
+			IssueType: "Sub-task",
+			Summary:   "roll back the deployment",
+			Assignee:  "jdoe",
+			Parent:    "FLYTE-1",
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestCreateSubtaskWithoutParent(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusCreated, struct{}{}))
+	input := []byte(`{"project": "FLYTE", "summary": "roll back the deployment"}`)
+
+	actualEvent := createSubtaskHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: createSubtaskFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "parent is required",
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestGetChildIssuesAsExpected(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/field":
+			w.Write([]byte(`[]`))
+		case "/rest/api/2/search":
+			w.Write([]byte(`{"total": 2, "issues": [
+				{"key": "FLYTE-2", "fields": {"summary": "first", "status": {"name": "Open"}, "issuetype": {"name": "Sub-task"}}},
+				{"key": "FLYTE-3", "fields": {"summary": "second", "status": {"name": "Done"}, "issuetype": {"name": "Sub-task"}}}
+			]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+	input := []byte(`{"issueId": "FLYTE-1"}`)
+
+	actualEvent := getChildIssuesHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: childIssuesEventDef,
+		Payload: childIssuesPayload{
+			IssueId:      "FLYTE-1",
+			MaxResults:   50,
+			TotalResults: 2,
+			Issues: []IssuePayload{
+				{Id: "FLYTE-2", Summary: "first", Status: "Open", IssueType: "Sub-task"},
+				{Id: "FLYTE-3", Summary: "second", Status: "Done", IssueType: "Sub-task"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestGetChildIssuesWithoutIssueId(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
+
+	payload := getChildIssuesHandler(r)([]byte(`{}`)).Payload.(failurePayload)
+
+	if payload.Code != "INVALID_INPUT" || payload.Error != "issueId is required" {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
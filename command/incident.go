@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+)
+
+var (
+	incidentIssuesEventDef = flyte.EventDef{
+		Name: "IncidentIssues",
+	}
+
+	incidentIssuesFailureEventDef = flyte.EventDef{
+		Name: "IncidentIssuesFailure",
+	}
+)
+
+func FindIssuesByIncidentCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "FindIssuesByIncident",
+		OutputEvents: []flyte.EventDef{incidentIssuesEventDef, incidentIssuesFailureEventDef},
+		Handler:      findIssuesByIncidentHandler(r),
+	}
+}
+
+type (
+	incidentIssuesRequest struct {
+		Incident   string `json:"incident"`
+		StartIndex int    `json:"startIndex"`
+		MaxResults int    `json:"maxResults"`
+		instanceSelector
+	}
+
+	incidentIssuesPayload struct {
+		Incident     string         `json:"incident"`
+		StartIndex   int            `json:"startIndex"`
+		MaxResults   int            `json:"maxResults"`
+		TotalResults int            `json:"total"`
+		Issues       []IssuePayload `json:"issues"`
+	}
+)
+
+func findIssuesByIncidentHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := incidentIssuesRequest{MaxResults: 50}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Find Issues By Incident Request [%s]: %s", input, err)
+			return newFailureEvent(incidentIssuesFailureEventDef, input, invalidInput(err))
+		}
+		if !incidentPattern(req.Incident) {
+			err := fmt.Errorf("%s: invalid incident number format", req.Incident)
+			return newFailureEvent(incidentIssuesFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, "")
+		if err != nil {
+			log.Printf("Error routing Find Issues By Incident Request for %s: %s", req.Incident, err)
+			return newFailureEvent(incidentIssuesFailureEventDef, input, err)
+		}
+
+		result, err := c.FindIssuesByIncident(req.Incident, req.StartIndex, req.MaxResults)
+		if err != nil {
+			err = fmt.Errorf("Could not find issues of incident: %w", err)
+			log.Println(err)
+			return newFailureEvent(incidentIssuesFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: incidentIssuesEventDef,
+			Payload: incidentIssuesPayload{
+				Incident:     req.Incident,
+				StartIndex:   req.StartIndex,
+				MaxResults:   req.MaxResults,
+				TotalResults: result.TotalResults,
+				Issues:       newIssuePayloads(result.Issues),
+			},
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFindIssuesByIncidentAsExpected(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/search" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"total": 1, "issues": [{"key": "FLYTE-1", "fields": {"summary": "checkout is down", "status": {"name": "Open"}}}]}`))
+	})
+
+	actualEvent := findIssuesByIncidentHandler(r)([]byte(`{"incident": "INC1234567"}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: incidentIssuesEventDef,
+		Payload: incidentIssuesPayload{
+			Incident:     "INC1234567",
+			MaxResults:   50,
+			TotalResults: 1,
+			Issues:       []IssuePayload{{Id: "FLYTE-1", Summary: "checkout is down", Status: "Open"}},
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestFindIssuesByIncidentInvalidIncident(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
+
+	payload := findIssuesByIncidentHandler(r)([]byte(`{"incident": "INC12"}`)).Payload.(failurePayload)
+
+	if payload.Code != "INVALID_INPUT" || payload.Error != "INC12: invalid incident number format" {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
+
+func TestCreateIncIssueLinksIncident(t *testing.T) {
+	var linked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			w.Write([]byte(`{"issues": []}`))
+		case "/rest/api/2/issue/":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "10001", "key": "FLYTE-1"}`))
+		case "/rest/api/2/issue/FLYTE-1/remotelink":
+			linked = true
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c, err := client.New(client.Config{
+		Host:     server.URL,
+		Incident: client.IncidentConfig{Target: client.IncidentRemoteLink, URLTemplate: "https://snow/{incident}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []byte(`{"project":"FLYTE","issuetype":"Story", "summary": "test story", "incident":"INC1234567"}`)
+
+	actualEvent := createIncIssueHandler(singleInstanceRouter(t, c))(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: createIncIssueEventDef,
+		Payload:  CreateIncIssueSuccess{ID: "10001", Key: "FLYTE-1", Incident: "INC1234567"},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) || !linked {
+		t.Errorf("Expected: %+v but got: %+v, linked: %v", expectedEvent, actualEvent, linked)
+	}
+}
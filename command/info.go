@@ -27,12 +27,6 @@ import (
 )
 
 var (
-	IssueInfoCommand = flyte.Command{
-		Name:         "IssueInfo",
-		OutputEvents: []flyte.EventDef{infoEventDef, infoFailureEventDef},
-		Handler:      infoHandler,
-	}
-
 	infoEventDef = flyte.EventDef{
 		Name: "Info",
 	}
@@ -42,6 +36,14 @@ var (
 	}
 )
 
+func IssueInfoCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "IssueInfo",
+		OutputEvents: []flyte.EventDef{infoEventDef, infoFailureEventDef},
+		Handler:      infoHandler(r),
+	}
+}
+
 type (
 	infoSuccessPayload struct {
 		Id          string `json:"id"`
@@ -56,36 +58,55 @@ type (
 		Type        string `json:"type"`
 	}
 
-	infoFailurePayload struct {
-		Id    string `json:"id"`
-		Error string `json:"error"`
+	// infoRequest accepts either a bare issue id/url string or an object
+	// carrying the id and the Jira instance to query.
+	infoRequest struct {
+		Id string `json:"id"`
+		instanceSelector
 	}
 )
 
-func infoHandler(input json.RawMessage) flyte.Event {
-	var in string
-	if err := json.Unmarshal(input, &in); err != nil {
-		log.Printf("Error unmarshaling input for IssueInfo: %s", err)
-		return newInfoFailureEvent("", err)
+func (in *infoRequest) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &in.Id); err == nil {
+		return nil
 	}
 
-	//`\w+-\d+ should resolve any regex of type <KEY-NUMBER>
-	re := regexp.MustCompile(`\w+-\d+`)
-	issueId := re.FindString(in)
-
-	issue, err := client.GetIssueInfo(issueId)
-	if err != nil {
-		log.Printf("Error fetching IssueInfo for %s: %s", issueId, err)
-		return newInfoFailureEvent(issueId, err)
+	var req struct {
+		Id string `json:"id"`
+		instanceSelector
 	}
-
-	return newInfoEvent(issue)
+	if err := json.Unmarshal(b, &req); err != nil {
+		return err
+	}
+	*in = infoRequest(req)
+	return nil
 }
 
-func newInfoFailureEvent(issueId string, err error) flyte.Event {
-	return flyte.Event{
-		EventDef: infoFailureEventDef,
-		Payload:  infoFailurePayload{issueId, err.Error()},
+func infoHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		in := infoRequest{}
+		if err := json.Unmarshal(input, &in); err != nil {
+			log.Printf("Error unmarshaling input for IssueInfo: %s", err)
+			return newFailureEvent(infoFailureEventDef, input, invalidInput(err))
+		}
+
+		//`\w+-\d+ should resolve any regex of type <KEY-NUMBER>
+		re := regexp.MustCompile(`\w+-\d+`)
+		issueId := re.FindString(in.Id)
+
+		c, err := r.Route(in.Instance, issueId)
+		if err != nil {
+			log.Printf("Error routing IssueInfo for %s: %s", issueId, err)
+			return newFailureEvent(infoFailureEventDef, input, err)
+		}
+
+		issue, err := c.GetIssueInfo(issueId)
+		if err != nil {
+			log.Printf("Error fetching IssueInfo for %s: %s", issueId, err)
+			return newFailureEvent(infoFailureEventDef, input, err)
+		}
+
+		return newInfoEvent(issue)
 	}
 }
 
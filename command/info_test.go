@@ -17,7 +17,9 @@ limitations under the License.
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
 	"github.com/ExpediaGroup/flyte-jira/client"
 	"github.com/ExpediaGroup/flyte-jira/domain"
 	"net/http"
@@ -33,17 +35,17 @@ type infoTest struct {
 }
 
 func TestGetInfoWorkingAsExpected(t *testing.T) {
-	initialFunc := client.SendRequest
-	defer func() { client.SendRequest = initialFunc }()
 	expectedIssueId := ""
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
+	r := newTestRouter(t, func(w http.ResponseWriter, request *http.Request) {
 		reqPath := request.URL.Path
 		issueId := path.Base(reqPath)
 		if issueId != expectedIssueId {
-			return http.StatusBadRequest, fmt.Errorf("expected issueId %s got %s", "DEVEX-553", issueId)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"errorMessages":["expected issueId %s got %s"]}`, expectedIssueId, issueId)
+			return
 		}
-		return http.StatusOK, nil
-	}
+		w.Write([]byte(`{}`))
+	})
 
 	testCases := []infoTest{
 		{"test-normal-input",
@@ -78,7 +80,7 @@ func TestGetInfoWorkingAsExpected(t *testing.T) {
 		t.Run(tCase.name, func(t *testing.T) {
 			in := tCase.rawJson
 			expectedIssueId = tCase.expIssueId
-			event := infoHandler([]byte(in))
+			event := infoHandler(r)([]byte(in))
 
 			expectedEvent := newInfoEvent(domain.Issue{})
 			if !reflect.DeepEqual(event, expectedEvent) {
@@ -89,17 +91,62 @@ func TestGetInfoWorkingAsExpected(t *testing.T) {
 }
 
 func TestGetInfoFailure(t *testing.T) {
-	initialFunc := client.SendRequest
-	defer func() { client.SendRequest = initialFunc }()
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusBadRequest, nil
-	}
+	r := newTestRouter(t, respondWith(http.StatusBadRequest, struct{}{}))
 	input := `"Test-123"`
-	actualEvent := infoHandler([]byte(input))
+	actualEvent := infoHandler(r)([]byte(input))
 
 	// Issue empty because it's populated in Send request
-	expectedEvent := newInfoFailureEvent("Test-123", fmt.Errorf("issueId=%s : statusCode=%d", "Test-123", 400))
+	expectedEvent := flyte.Event{
+		EventDef: infoFailureEventDef,
+		Payload: failurePayload{
+			Code:           "BAD_REQUEST",
+			Error:          "issueId=Test-123 : statusCode=400",
+			failureDetails: failureDetails{StatusCode: 400},
+			Input:          json.RawMessage(input),
+		},
+	}
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %v but got: %v", expectedEvent, actualEvent)
 	}
 }
+
+func TestGetInfoRoutesToRequestedInstance(t *testing.T) {
+	onprem := newTestClient(t, func(w http.ResponseWriter, request *http.Request) {
+		t.Errorf("unexpected request to onprem instance: %s", request.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	cloud := newTestClient(t, respondWith(http.StatusOK, domain.Issue{Key: "OPS-1"}))
+	r, err := client.NewRouter("onprem",
+		client.Instance{Name: "onprem", Client: onprem},
+		client.Instance{Name: "cloud", Client: cloud},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := infoHandler(r)([]byte(`{"id": "OPS-1", "instance": "cloud"}`))
+
+	expectedEvent := newInfoEvent(domain.Issue{Key: "OPS-1"})
+	if !reflect.DeepEqual(event, expectedEvent) {
+		t.Errorf("Expected: %v but got: %v", expectedEvent, event)
+	}
+}
+
+func TestGetInfoUnknownInstance(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, domain.Issue{Key: "OPS-1"}))
+	input := []byte(`{"id": "OPS-1", "instance": "staging"}`)
+
+	event := infoHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: infoFailureEventDef,
+		Payload: failurePayload{
+			Code:  "UNKNOWN_INSTANCE",
+			Error: `unknown jira instance "staging"`,
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(event, expectedEvent) {
+		t.Errorf("Expected: %v but got: %v", expectedEvent, event)
+	}
+}
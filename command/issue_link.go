@@ -8,108 +8,124 @@ import (
 )
 
 var (
-	IssueCreateLinkCommand = flyte.Command{
+	linkEventDef = flyte.EventDef{
+		Name: "Link",
+	}
+
+	linkFailureEventDef = flyte.EventDef{
+		Name: "LinkFailure",
+	}
+)
+
+func IssueCreateLinkCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
 		Name:         "IssueCreateLink",
 		OutputEvents: []flyte.EventDef{linkEventDef, linkFailureEventDef},
-		Handler:      issueCreateLinkHandler,
+		Handler:      issueCreateLinkHandler(r),
 	}
+}
 
-	IssueGetLinkCommand = flyte.Command{
+func IssueGetLinkCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
 		Name:         "IssueGetLink",
 		OutputEvents: []flyte.EventDef{linkEventDef, linkFailureEventDef},
-		Handler:      issueGetLinkHandler,
+		Handler:      issueGetLinkHandler(r),
 	}
+}
 
-	IssueDeleteLinkCommand = flyte.Command{
+func IssueDeleteLinkCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
 		Name:         "IssueDeleteLink",
 		OutputEvents: []flyte.EventDef{linkEventDef, linkFailureEventDef},
-		Handler:      issueDeleteLinkHandler,
-	}
-
-	linkEventDef = flyte.EventDef{
-		Name: "Link",
-	}
-
-	linkFailureEventDef = flyte.EventDef{
-		Name: "LinkFailure",
+		Handler:      issueDeleteLinkHandler(r),
 	}
-)
+}
 
 type (
-	linkFailurePayload struct {
-		linkRequest
-		Error string `json:"error"`
-	}
-
 	linkRequest struct {
 		LinkId       string `json:"linkId,omitempty"`
 		InwardIssue  string `json:"inwardIssue,omitempty"`
 		OutwardIssue string `json:"outwardIssue,omitempty"`
 		LinkType     string `json:"linkType,omitempty"`
+		instanceSelector
 	}
 )
 
-func issueGetLinkHandler(input json.RawMessage) flyte.Event {
-	req := linkRequest{}
-	if err := json.Unmarshal(input, &req); err != nil {
-		log.Printf("Error unmarshaling Issue Link Request [%s]: %s", input, err)
-		return newLinkFailureEvent(req, err)
-	}
-
-	resp, err := client.GetLink(req.LinkId)
-	if err != nil {
-		log.Printf("Error fetching link %s: %s", req.LinkId, err)
-		return newLinkFailureEvent(req, err)
-	}
-
-	return flyte.Event{
-		EventDef: linkEventDef,
-		Payload:  resp,
+func issueGetLinkHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := linkRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Issue Link Request [%s]: %s", input, err)
+			return newFailureEvent(linkFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, "")
+		if err != nil {
+			log.Printf("Error routing Issue Link Request for link %s: %s", req.LinkId, err)
+			return newFailureEvent(linkFailureEventDef, input, err)
+		}
+
+		resp, err := c.GetLink(req.LinkId)
+		if err != nil {
+			log.Printf("Error fetching link %s: %s", req.LinkId, err)
+			return newFailureEvent(linkFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: linkEventDef,
+			Payload:  resp,
+		}
 	}
 }
 
-func issueCreateLinkHandler(input json.RawMessage) flyte.Event {
-	req := linkRequest{}
-	if err := json.Unmarshal(input, &req); err != nil {
-		log.Printf("Error unmarshaling Issue Link Request [%s]: %s", input, err)
-		return newLinkFailureEvent(req, err)
-	}
-
-	if err := client.LinkIssues(req.InwardIssue, req.OutwardIssue, req.LinkType); err != nil {
-		log.Printf("Error linking Issue %s to Issue %s with type %s: %s", req.InwardIssue, req.OutwardIssue, req.LinkType, err)
-		return newLinkFailureEvent(req, err)
-	}
-
-	return flyte.Event{
-		EventDef: linkEventDef,
-		Payload:  req,
-	}
-}
-
-func issueDeleteLinkHandler(input json.RawMessage) flyte.Event {
-	req := linkRequest{}
-	if err := json.Unmarshal(input, &req); err != nil {
-		log.Printf("Error unmarshaling Issue Link Request [%s]: %s", input, err)
-		return newLinkFailureEvent(req, err)
-	}
-
-	if err := client.DeleteLink(req.LinkId); err != nil {
-		log.Printf("Error removing link %s: %s", req.LinkId, err)
-		return newLinkFailureEvent(req, err)
-	}
-
-	return flyte.Event{
-		EventDef: linkEventDef,
-		Payload:  req,
+func issueCreateLinkHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := linkRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Issue Link Request [%s]: %s", input, err)
+			return newFailureEvent(linkFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, req.InwardIssue)
+		if err != nil {
+			log.Printf("Error routing Issue Link Request for Issue %s: %s", req.InwardIssue, err)
+			return newFailureEvent(linkFailureEventDef, input, err)
+		}
+
+		if err := c.LinkIssues(req.InwardIssue, req.OutwardIssue, req.LinkType); err != nil {
+			log.Printf("Error linking Issue %s to Issue %s with type %s: %s", req.InwardIssue, req.OutwardIssue, req.LinkType, err)
+			return newFailureEvent(linkFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: linkEventDef,
+			Payload:  req,
+		}
 	}
 }
 
-func newLinkFailureEvent(request linkRequest, err error) flyte.Event {
-	return flyte.Event{
-		EventDef: linkFailureEventDef,
-		Payload: linkFailurePayload{
-			request,
-			err.Error(),
-		},
+func issueDeleteLinkHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := linkRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Issue Link Request [%s]: %s", input, err)
+			return newFailureEvent(linkFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, "")
+		if err != nil {
+			log.Printf("Error routing Issue Link Request for link %s: %s", req.LinkId, err)
+			return newFailureEvent(linkFailureEventDef, input, err)
+		}
+
+		if err := c.DeleteLink(req.LinkId); err != nil {
+			log.Printf("Error removing link %s: %s", req.LinkId, err)
+			return newFailureEvent(linkFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: linkEventDef,
+			Payload:  req,
+		}
 	}
 }
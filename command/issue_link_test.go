@@ -2,7 +2,6 @@ package command
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"path"
@@ -14,8 +13,6 @@ import (
 )
 
 func TestLinkCreateIsSuccessful(t *testing.T) {
-	initialFunc := client.SendRequestWithoutResp
-	defer func() { client.SendRequestWithoutResp = initialFunc }()
 	in := []byte(`{
         "inwardIssue": "TEST-123",
         "outwardIssue": "Test-321",
@@ -24,28 +21,32 @@ func TestLinkCreateIsSuccessful(t *testing.T) {
 
 	expHttpReq := client.LinkIssueRequest{
 		LinkType: client.IssueLink{Name: "Depends"},
-		Inward:   client.LinkIssue{"TEST-123"},
-		Outward:  client.LinkIssue{"Test-321"},
-		Comment:  client.Comment{"Link related issues!"},
+		Inward:   client.LinkIssue{Key: "TEST-123"},
+		Outward:  client.LinkIssue{Key: "Test-321"},
+		Comment:  client.Comment{Body: "Link related issues!"},
 	}
-	client.SendRequestWithoutResp = func(request *http.Request) (int, error) {
+	r := newTestRouter(t, func(w http.ResponseWriter, request *http.Request) {
 		b, err := ioutil.ReadAll(request.Body)
 		if err != nil {
-			return 400, err
+			w.WriteHeader(400)
+			return
 		}
 
 		body := client.LinkIssueRequest{}
 		if err := json.Unmarshal(b, &body); err != nil {
-			return 400, err
+			w.WriteHeader(400)
+			return
 		}
 
 		if !reflect.DeepEqual(expHttpReq, body) {
-			return http.StatusNotFound, fmt.Errorf("expHttpRequest: %v actual: %v", expHttpReq, body)
+			t.Errorf("expHttpRequest: %v actual: %v", expHttpReq, body)
+			w.WriteHeader(http.StatusNotFound)
+			return
 		}
 
-		return http.StatusOK, nil
-	}
-	actualEvent := issueCreateLinkHandler(in)
+		w.WriteHeader(http.StatusCreated)
+	})
+	actualEvent := issueCreateLinkHandler(r)(in)
 	expectedEvent := flyte.Event{
 		EventDef: linkEventDef,
 		Payload: linkRequest{
@@ -61,36 +62,31 @@ func TestLinkCreateIsSuccessful(t *testing.T) {
 }
 
 func TestLinkGetIsSuccessful(t *testing.T) {
-	initialFunc := client.SendRequest
-	defer func() { client.SendRequest = initialFunc }()
 	in := []byte(`{"linkId": "1223"}`)
 
 	expLinkId := "1223"
-	client.SendRequest = func(request *http.Request, respB interface{}) (responseCode int, err error) {
+	r := newTestRouter(t, func(w http.ResponseWriter, request *http.Request) {
 		reqPath := request.URL.Path
 		linkId := path.Base(reqPath)
 		if linkId != expLinkId {
-			return http.StatusBadRequest, fmt.Errorf("expected issueId %s got %s", "DEVEX-553", linkId)
-		}
-
-		switch v := respB.(type) {
-		case **client.LinkIssueRequest:
-			{
-				(*v).Inward = client.LinkIssue{"TEST-123"}
-				(*v).Outward = client.LinkIssue{"TEST-321"}
-				(*v).LinkType = client.IssueLink{Name: "Depends"}
-			}
+			t.Errorf("expected linkId %s got %s", expLinkId, linkId)
+			w.WriteHeader(http.StatusBadRequest)
+			return
 		}
 
-		return http.StatusOK, nil
-	}
+		json.NewEncoder(w).Encode(client.LinkIssueRequest{
+			Inward:   client.LinkIssue{Key: "TEST-123"},
+			Outward:  client.LinkIssue{Key: "TEST-321"},
+			LinkType: client.IssueLink{Name: "Depends"},
+		})
+	})
 
-	actualEvent := issueGetLinkHandler(in)
+	actualEvent := issueGetLinkHandler(r)(in)
 	expectedEvent := flyte.Event{
 		EventDef: linkEventDef,
 		Payload: &client.LinkIssueRequest{
-			Inward:   client.LinkIssue{"TEST-123"},
-			Outward:  client.LinkIssue{"TEST-321"},
+			Inward:   client.LinkIssue{Key: "TEST-123"},
+			Outward:  client.LinkIssue{Key: "TEST-321"},
 			LinkType: client.IssueLink{Name: "Depends"},
 		},
 	}
@@ -101,22 +97,22 @@ func TestLinkGetIsSuccessful(t *testing.T) {
 }
 
 func TestLinkDeleteIsSuccessful(t *testing.T) {
-	initialFunc := client.SendRequestWithoutResp
-	defer func() { client.SendRequestWithoutResp = initialFunc }()
 	in := []byte(`{"linkId": "1223"}`)
 
 	expLinkId := "1223"
-	client.SendRequestWithoutResp = func(request *http.Request) (int, error) {
+	r := newTestRouter(t, func(w http.ResponseWriter, request *http.Request) {
 		reqPath := request.URL.Path
 		linkId := path.Base(reqPath)
 		if linkId != expLinkId {
-			return http.StatusBadRequest, fmt.Errorf("expected issueId %s got %s", "DEVEX-553", linkId)
+			t.Errorf("expected linkId %s got %s", expLinkId, linkId)
+			w.WriteHeader(http.StatusBadRequest)
+			return
 		}
 
-		return http.StatusOK, nil
-	}
+		w.WriteHeader(http.StatusNoContent)
+	})
 
-	actualEvent := issueDeleteLinkHandler(in)
+	actualEvent := issueDeleteLinkHandler(r)(in)
 	expectedEvent := flyte.Event{
 		EventDef: linkEventDef,
 		Payload: linkRequest{
@@ -15,41 +15,71 @@ var (
 	searchFailureEventDef = flyte.EventDef{Name: "SearchFailure"}
 )
 
-var SearchIssuesCommand = flyte.Command{
-	Name:         "SearchIssues",
-	OutputEvents: []flyte.EventDef{searchSuccessEventDef, searchFailureEventDef},
-	Handler:      searchIssuesHandler,
+func SearchIssuesCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "SearchIssues",
+		OutputEvents: []flyte.EventDef{searchSuccessEventDef, searchFailureEventDef},
+		Handler:      searchIssuesHandler(r),
+	}
 }
 
-func searchIssuesHandler(rawInput json.RawMessage) flyte.Event {
+func searchIssuesHandler(r *client.Router) flyte.CommandHandler {
+	return func(rawInput json.RawMessage) flyte.Event {
 
-	input := SearchIssuesInput{"", 0, 10}
-	if err := json.Unmarshal(rawInput, &input); err != nil {
-		err := fmt.Errorf("input is not valid: %s", err)
-		return flyte.NewFatalEvent(err)
-	}
+		var input struct {
+			SearchIssuesInput
+			// PageToken is the nextPageToken of the previous page, on
+			// Jira Cloud.
+			PageToken string `json:"pageToken"`
+			instanceSelector
+		}
+		input.SearchIssuesInput = SearchIssuesInput{"", 0, 10}
+		if err := json.Unmarshal(rawInput, &input); err != nil {
+			err := fmt.Errorf("input is not valid: %s", err)
+			return newFailureEvent(searchFailureEventDef, rawInput, invalidInput(err))
+		}
 
-	if input.Query == "" {
-		err := errors.New("Empty query string")
-		return newSearchFailureEvent(input, err)
-	}
+		if input.Query == "" {
+			err := errors.New("Empty query string")
+			return newFailureEvent(searchFailureEventDef, rawInput, invalidInput(err))
+		}
 
-	searchResult, err := client.SearchIssues(input.Query, input.StartIndex, input.MaxResults)
-	if err != nil {
-		err := fmt.Errorf("Could not search for issues: %s", err)
-		log.Println(err)
-		return newSearchFailureEvent(input, err)
-	}
+		c, err := r.Route(input.Instance, "")
+		if err != nil {
+			log.Println(err)
+			return newFailureEvent(searchFailureEventDef, rawInput, err)
+		}
+
+		var searchResult client.SearchResult
+		if input.PageToken != "" {
+			searchResult, err = c.SearchIssuesPage(input.Query, input.PageToken, input.MaxResults)
+		} else {
+			searchResult, err = c.SearchIssues(input.Query, input.StartIndex, input.MaxResults)
+		}
+		if err != nil {
+			err := fmt.Errorf("Could not search for issues: %w", err)
+			log.Println(err)
+			return newFailureEvent(searchFailureEventDef, rawInput, err)
+		}
 
-	return newSearchSuccessEvent(
-		input,
-		searchResult.TotalResults,
-		searchResult.Issues)
+		return newSearchSuccessEvent(
+			input.SearchIssuesInput,
+			searchResult.TotalResults,
+			searchResult.Issues,
+			searchResult.NextPageToken)
+	}
 }
 
-func newSearchSuccessEvent(input SearchIssuesInput, totalResults int, unformattedIssues []domain.Issue) flyte.Event {
+func newSearchSuccessEvent(input SearchIssuesInput, totalResults int, unformattedIssues []domain.Issue, nextPageToken string) flyte.Event {
 
 	inputDetails := SearchIssuesInput{input.Query, input.StartIndex, input.MaxResults}
+	return flyte.Event{
+		EventDef: searchSuccessEventDef,
+		Payload:  SearchSuccessOutput{inputDetails, totalResults, newIssuePayloads(unformattedIssues), nextPageToken},
+	}
+}
+
+func newIssuePayloads(unformattedIssues []domain.Issue) []IssuePayload {
 	var issues []IssuePayload
 	for _, issue := range unformattedIssues {
 		formattedIssue := IssuePayload{
@@ -57,23 +87,20 @@ func newSearchSuccessEvent(input SearchIssuesInput, totalResults int, unformatte
 			Summary:     issue.Fields.Summary,
 			Status:      issue.Fields.Status.Name,
 			Description: issue.Fields.Description,
-			Assignee:    issue.Fields.Assignee.Name,
+			Assignee:    userID(issue.Fields.Assignee),
+			IssueType:   issue.Fields.Type.Name,
 		}
 		issues = append(issues, formattedIssue)
 	}
-	return flyte.Event{
-		EventDef: searchSuccessEventDef,
-		Payload:  SearchSuccessOutput{inputDetails, totalResults, issues},
-	}
+	return issues
 }
 
-func newSearchFailureEvent(input SearchIssuesInput, error error) flyte.Event {
-
-	inputDetails := SearchIssuesInput{input.Query, input.StartIndex, input.MaxResults}
-	return flyte.Event{
-		EventDef: searchFailureEventDef,
-		Payload:  SearchFailureOutput{inputDetails, error.Error()},
+// userID returns the name of user, or its account id on Jira Cloud.
+func userID(user domain.User) string {
+	if user.Name == "" {
+		return user.AccountID
 	}
+	return user.Name
 }
 
 type SearchIssuesInput struct {
@@ -86,11 +113,9 @@ type SearchSuccessOutput struct {
 	SearchIssuesInput
 	TotalResults int            `json:"total"`
 	Issues       []IssuePayload `json:"issues"`
-}
-
-type SearchFailureOutput struct {
-	SearchIssuesInput
-	Error string `json:"error"`
+	// NextPageToken fetches the next page on Jira Cloud, see
+	// client.SearchIssuesPage.
+	NextPageToken string `json:"nextPageToken,omitempty"`
 }
 
 type IssuePayload struct {
@@ -99,4 +124,5 @@ type IssuePayload struct {
 	Status      string `json:"status"`
 	Description string `json:"description"`
 	Assignee    string `json:"assignee"`
+	IssueType   string `json:"issuetype,omitempty"`
 }
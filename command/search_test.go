@@ -17,25 +17,23 @@ limitations under the License.
 package command
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/ExpediaGroup/flyte-client/flyte"
 	"github.com/ExpediaGroup/flyte-jira/client"
 	"github.com/ExpediaGroup/flyte-jira/domain"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 )
 
 func TestSearchIssuesSuccess(t *testing.T) {
-	initialSendRequest := client.SendRequest
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusOK, nil
-	}
-	defer func() { client.SendRequest = initialSendRequest }()
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
 
-	actualEvent := searchIssuesHandler([]byte(`{"query": "project = FLYTE"}`))
+	actualEvent := searchIssuesHandler(r)([]byte(`{"query": "project = FLYTE"}`))
 
-	expectedEvent := newSearchSuccessEvent(SearchIssuesInput{"project = FLYTE", 0, 10}, 0, nil)
+	expectedEvent := newSearchSuccessEvent(SearchIssuesInput{"project = FLYTE", 0, 10}, 0, nil, "")
 
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
@@ -43,14 +41,19 @@ func TestSearchIssuesSuccess(t *testing.T) {
 }
 
 func TestSearchIssuesFailure(t *testing.T) {
-	initialSendRequest := client.SendRequest
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return http.StatusBadRequest, nil
+	r := newTestRouter(t, respondWith(http.StatusBadRequest, struct{}{}))
+
+	input := []byte(`{"query": "project = FLYTE"}`)
+	actualEvent := searchIssuesHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: searchFailureEventDef,
+		Payload: failurePayload{
+			Code:           "BAD_REQUEST",
+			Error:          "Could not search for issues: query='project = FLYTE' : statusCode=400",
+			failureDetails: failureDetails{StatusCode: 400},
+			Input:          json.RawMessage(input),
+		},
 	}
-	defer func() { client.SendRequest = initialSendRequest }()
-
-	actualEvent := searchIssuesHandler([]byte(`{"query": "project = FLYTE"}`))
-	expectedEvent := newSearchFailureEvent(SearchIssuesInput{"project = FLYTE", 0, 10}, errors.New("Could not search for issues: query='project = FLYTE' : statusCode=400"))
 
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
@@ -58,8 +61,17 @@ func TestSearchIssuesFailure(t *testing.T) {
 }
 
 func TestSearchIssuesEmptyQuery(t *testing.T) {
-	actualEvent := searchIssuesHandler([]byte(`{"query": ""}`))
-	expectedEvent := newSearchFailureEvent(SearchIssuesInput{"", 0, 10}, errors.New("Empty query string"))
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
+	input := []byte(`{"query": ""}`)
+	actualEvent := searchIssuesHandler(r)(input)
+	expectedEvent := flyte.Event{
+		EventDef: searchFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "Empty query string",
+			Input: json.RawMessage(input),
+		},
+	}
 
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
@@ -67,14 +79,26 @@ func TestSearchIssuesEmptyQuery(t *testing.T) {
 }
 
 func TestRequestError(t *testing.T) {
-	initialSendRequest := client.SendRequest
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		return -1, errors.New("request timed out")
+	c, err := client.New(client.Config{Host: "http://jira.test"}, client.WithHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("request timed out")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
 	}
-	defer func() { client.SendRequest = initialSendRequest }()
 
-	actualEvent := searchIssuesHandler([]byte(`{"query": "project = FLYTE"}`))
-	expectedEvent := newSearchFailureEvent(SearchIssuesInput{"project = FLYTE", 0, 10}, errors.New("Could not search for issues: query='project = FLYTE' : error=request timed out"))
+	input := []byte(`{"query": "project = FLYTE"}`)
+	actualEvent := searchIssuesHandler(singleInstanceRouter(t, c))(input)
+	expectedEvent := flyte.Event{
+		EventDef: searchFailureEventDef,
+		Payload: failurePayload{
+			Code:      "CONNECTION_ERROR",
+			Error:     `Could not search for issues: query='project = FLYTE' : Post "http://jira.test/rest/api/2/search": request timed out`,
+			Retryable: true,
+			Input:     json.RawMessage(input),
+		},
+	}
 
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
@@ -82,32 +106,75 @@ func TestRequestError(t *testing.T) {
 }
 
 func TestIssueFormatting(t *testing.T) {
-	initialSendRequest := client.SendRequest
-	client.SendRequest = func(request *http.Request, responseBody interface{}) (int, error) {
-		p := client.SearchResult{
-			TotalResults: 2,
-			Issues:       []domain.Issue{createDummyIssue(), createDummyIssue()},
-		}
+	r := newTestRouter(t, respondWith(http.StatusOK, client.SearchResult{
+		TotalResults: 2,
+		Issues:       []domain.Issue{createDummyIssue(), createDummyIssue()},
+	}))
 
-		reflect.ValueOf(responseBody).Elem().Set(reflect.ValueOf(&p).Elem())
+	actualEvent := searchIssuesHandler(r)([]byte(`{"query": "project = FLYTE"}`))
+	expectedEvent := newSearchSuccessEvent(SearchIssuesInput{"project = FLYTE", 0, 10}, 2, []domain.Issue{createDummyIssue(), createDummyIssue()}, "")
 
-		return http.StatusOK, nil
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
 	}
-	defer func() { client.SendRequest = initialSendRequest }()
+}
 
-	actualEvent := searchIssuesHandler([]byte(`{"query": "project = FLYTE"}`))
-	expectedEvent := newSearchSuccessEvent(SearchIssuesInput{"project = FLYTE", 0, 10}, 2, []domain.Issue{createDummyIssue(), createDummyIssue()})
+func TestInvalidInput(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
+	actualEvent := searchIssuesHandler(r)([]byte(`{]`))
+	expectedEvent := flyte.Event{
+		EventDef: searchFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: "input is not valid: invalid character ']' looking for beginning of object key string",
+			Input: "{]",
+		},
+	}
 
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
 	}
 }
 
-func TestInvalidInput(t *testing.T) {
-	actualEvent := searchIssuesHandler([]byte(`{]`))
-	expectedEvent := flyte.NewFatalEvent(errors.New("input is not valid: invalid character ']' looking for beginning of object key string"))
+func TestSearchIssuesByPageTokenOnCloud(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/rest/api/3/search/jql" || body["nextPageToken"] != "page-2" {
+			t.Errorf("unexpected request: %s %v", r.URL.Path, body)
+		}
+		w.Write([]byte(`{"issues": [{"key": "FLYTE-11", "fields": {"assignee": {"accountId": "5b10ac8d82e05b22cc7d4ef5"},
+			"description": {"type": "doc", "version": 1, "content": [{"type": "paragraph", "content": [{"type": "text", "text": "broken"}]}]}}}],
+			"isLast": true}`))
+	}))
+	t.Cleanup(server.Close)
+	c, err := client.New(client.Config{Host: server.URL, Deployment: client.DeploymentCloud})
+	if err != nil {
+		t.Fatal(err)
+	}
 
+	actualEvent := searchIssuesHandler(singleInstanceRouter(t, c))([]byte(`{"query": "project = FLYTE", "pageToken": "page-2", "maxResults": 10}`))
+
+	expectedEvent := flyte.Event{
+		EventDef: searchSuccessEventDef,
+		Payload: SearchSuccessOutput{
+			SearchIssuesInput: SearchIssuesInput{"project = FLYTE", 0, 10},
+			TotalResults:      1,
+			Issues:            []IssuePayload{{Id: "FLYTE-11", Description: "broken", Assignee: "5b10ac8d82e05b22cc7d4ef5"}},
+		},
+	}
 	if !reflect.DeepEqual(actualEvent, expectedEvent) {
 		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
 	}
 }
+
+func TestSearchIssuesByPageTokenOnServer(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, struct{}{}))
+
+	actualEvent := searchIssuesHandler(r)([]byte(`{"query": "project = FLYTE", "pageToken": "page-2"}`))
+
+	payload := actualEvent.Payload.(failurePayload)
+	if payload.Code != "NOT_SUPPORTED" {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
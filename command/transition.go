@@ -8,12 +8,6 @@ import (
 )
 
 var (
-	Transition = flyte.Command{
-		Name:         "Transition",
-		OutputEvents: []flyte.EventDef{transitionEventDef, transitionFailureEventDef},
-		Handler:      transitionHandler,
-	}
-
 	transitionEventDef = flyte.EventDef{
 		Name: "Transition",
 	}
@@ -23,9 +17,18 @@ var (
 	}
 )
 
+func Transition(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "Transition",
+		OutputEvents: []flyte.EventDef{transitionEventDef, transitionFailureEventDef},
+		Handler:      transitionHandler(r),
+	}
+}
+
 type transitionRequest struct {
 	IssueId      string `json:"issueId"`
 	TransitionId string `json:"transitionId"`
+	instanceSelector
 }
 
 type transitionPayload struct {
@@ -34,43 +37,34 @@ type transitionPayload struct {
 	RequestURL   string `json:"requestURL"`
 }
 
-type transitionFailurePayload struct {
-	IssueId      string `json:"issueId"`
-	TransitionId string `json:"transitionId"`
-	Error        string `json:"error"`
-}
-
-func transitionHandler(input json.RawMessage) flyte.Event {
-	req := transitionRequest{}
-	if err := json.Unmarshal(input, &req); err != nil {
-		log.Printf("Error unmarshaling transition request [%s]: %s", input, err)
-		return transitionFailureEvent(req, err)
-	}
+func transitionHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := transitionRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling transition request [%s]: %s", input, err)
+			return newFailureEvent(transitionFailureEventDef, input, invalidInput(err))
+		}
 
-	reqURL, err := client.Transition(req.IssueId, req.TransitionId)
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing transition request for issue %s: %s", req.IssueId, err)
+			return newFailureEvent(transitionFailureEventDef, input, err)
+		}
 
-	if err != nil {
-		log.Printf("Error during a transition for issue %s: %s", req.IssueId, err)
-		return transitionFailureEvent(req, err)
-	}
+		reqURL, err := c.Transition(req.IssueId, req.TransitionId)
 
-	return flyte.Event{
-		EventDef: transitionEventDef,
-		Payload: transitionPayload{
-			IssueId:      req.IssueId,
-			TransitionId: req.TransitionId,
-			RequestURL:   reqURL,
-		},
-	}
-}
+		if err != nil {
+			log.Printf("Error during a transition for issue %s: %s", req.IssueId, err)
+			return newFailureEvent(transitionFailureEventDef, input, err)
+		}
 
-func transitionFailureEvent(request transitionRequest, err error) flyte.Event {
-	return flyte.Event{
-		EventDef: transitionFailureEventDef,
-		Payload: transitionFailurePayload{
-			request.IssueId,
-			request.TransitionId,
-			err.Error(),
-		},
+		return flyte.Event{
+			EventDef: transitionEventDef,
+			Payload: transitionPayload{
+				IssueId:      req.IssueId,
+				TransitionId: req.TransitionId,
+				RequestURL:   reqURL,
+			},
+		}
 	}
 }
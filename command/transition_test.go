@@ -3,7 +3,6 @@ package command
 import (
 	"encoding/json"
 	"github.com/ExpediaGroup/flyte-client/flyte"
-	"github.com/ExpediaGroup/flyte-jira/client"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"net/http"
@@ -18,38 +17,40 @@ type TransitionRequest struct {
 	Transition mockRequestBody `json:"transition"`
 }
 
-func createMockSendRequest(issueId, transitionId string) func(request *http.Request) (int, error) {
-	return func(request *http.Request) (int, error) {
+func createMockSendRequest(issueId, transitionId string) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
 		b, err := ioutil.ReadAll(request.Body)
 		if err != nil {
-			return 400, err
+			w.WriteHeader(400)
+			return
 		}
 
 		body := &TransitionRequest{}
 
 		if err := json.Unmarshal(b, &body); err != nil {
-			return 400, err
+			w.WriteHeader(400)
+			return
 		}
 
 		if body.Transition.TransitionId != transitionId {
-			return 500, err
+			w.WriteHeader(500)
+			return
 		}
 
 		if issueId != "DEVEX-123" {
-			return 404, err
+			w.Header().Set("X-AREQUESTID", "760x1234x1")
+			w.WriteHeader(404)
+			w.Write([]byte(`{"errorMessages":["Issue does not exist or you do not have permission to see it."],"errors":{}}`))
+			return
 		}
 
-		return 204, nil
+		w.WriteHeader(204)
 	}
 }
 func TestTransitionAsExpected(t *testing.T) {
-	prevState := client.SendRequestWithoutResp
-	defer func() {
-		client.SendRequestWithoutResp = prevState
-	}()
-	client.SendRequestWithoutResp = createMockSendRequest("DEVEX-123", "881")
+	r := newTestRouter(t, createMockSendRequest("DEVEX-123", "881"))
 	input := []byte(`{"issueId":"DEVEX-123","transitionId":"881"}`)
-	actualEvent := transitionHandler(input)
+	actualEvent := transitionHandler(r)(input)
 	expEvent := flyte.Event{
 		EventDef: transitionEventDef,
 		Payload: transitionPayload{
@@ -62,38 +63,37 @@ func TestTransitionAsExpected(t *testing.T) {
 }
 
 func TestTransitionFailure_IssueOrUserDoesNotExist(t *testing.T) {
-	prevState := client.SendRequestWithoutResp
-	defer func() {
-		client.SendRequestWithoutResp = prevState
-	}()
-	client.SendRequestWithoutResp = createMockSendRequest("DEVEX-12333333", "881")
+	r := newTestRouter(t, createMockSendRequest("DEVEX-12333333", "881"))
 	input := []byte(`{"issueId":"DEVEX-12333333","transitionId":"881"}`)
-	actual := transitionHandler(input)
+	actual := transitionHandler(r)(input)
 	exp := flyte.Event{
 		EventDef: transitionFailureEventDef,
-		Payload: transitionFailurePayload{
-			IssueId:      "DEVEX-12333333",
-			TransitionId: "881",
-			Error:        "issue or user does not exist",
+		Payload: failurePayload{
+			Code:  "NOT_FOUND",
+			Error: "issueId=DEVEX-12333333 transitionId=881 : statusCode=404 : Issue does not exist or you do not have permission to see it. (requestId=760x1234x1)",
+			failureDetails: failureDetails{
+				StatusCode:    404,
+				ErrorMessages: []string{"Issue does not exist or you do not have permission to see it."},
+				RequestID:     "760x1234x1",
+			},
+			Input: json.RawMessage(input),
 		},
 	}
 	assert.Equal(t, exp, actual)
 }
 
 func TestTransitionFailure_TransitionDoesNotExist(t *testing.T) {
-	prevState := client.SendRequestWithoutResp
-	defer func() {
-		client.SendRequestWithoutResp = prevState
-	}()
-	client.SendRequestWithoutResp = createMockSendRequest("DEVEX-123", "881")
+	r := newTestRouter(t, createMockSendRequest("DEVEX-123", "881"))
 	input := []byte(`{"issueId":"DEVEX-123","transitionId":"123456"}`)
-	actual := transitionHandler(input)
+	actual := transitionHandler(r)(input)
 	exp := flyte.Event{
 		EventDef: transitionFailureEventDef,
-		Payload: transitionFailurePayload{
-			IssueId:      "DEVEX-123",
-			TransitionId: "123456",
-			Error:        "unsupported status code 500",
+		Payload: failurePayload{
+			Code:           "JIRA_ERROR",
+			Error:          "issueId=DEVEX-123 transitionId=123456 : statusCode=500",
+			Retryable:      true,
+			failureDetails: failureDetails{StatusCode: 500},
+			Input:          json.RawMessage(input),
 		},
 	}
 	assert.Equal(t, exp, actual)
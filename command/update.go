@@ -0,0 +1,109 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+)
+
+var (
+	issueUpdatedEventDef = flyte.EventDef{
+		Name: "IssueUpdated",
+	}
+
+	issueUpdateFailureEventDef = flyte.EventDef{
+		Name: "IssueUpdateFailure",
+	}
+)
+
+func UpdateIssueCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "UpdateIssue",
+		OutputEvents: []flyte.EventDef{issueUpdatedEventDef, issueUpdateFailureEventDef},
+		Handler:      updateIssueHandler(r),
+	}
+}
+
+type (
+	updateRequest struct {
+		IssueId string `json:"issueId"`
+		client.IssueUpdate
+		instanceSelector
+	}
+
+	issueUpdatedPayload struct {
+		IssueId string `json:"issueId"`
+		Url     string `json:"url"`
+		client.IssueUpdate
+	}
+)
+
+func updateIssueHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := updateRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Update Issue Request [%s]: %s", input, err)
+			return newFailureEvent(issueUpdateFailureEventDef, input, invalidInput(err))
+		}
+		if err := req.validate(); err != nil {
+			return newFailureEvent(issueUpdateFailureEventDef, input, invalidInput(err))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueId)
+		if err != nil {
+			log.Printf("Error routing Update Issue Request for %s: %s", req.IssueId, err)
+			return newFailureEvent(issueUpdateFailureEventDef, input, err)
+		}
+
+		if err := c.UpdateIssue(req.IssueId, req.IssueUpdate); err != nil {
+			err = fmt.Errorf("Could not update issue: %w", err)
+			log.Println(err)
+			return newFailureEvent(issueUpdateFailureEventDef, input, err)
+		}
+
+		return flyte.Event{
+			EventDef: issueUpdatedEventDef,
+			Payload: issueUpdatedPayload{
+				IssueId:     req.IssueId,
+				Url:         fmt.Sprintf("%s/browse/%s", c.BrowseURL(), req.IssueId),
+				IssueUpdate: req.IssueUpdate,
+			},
+		}
+	}
+}
+
+func (req updateRequest) validate() error {
+	if req.IssueId == "" {
+		return errors.New("issueId is required")
+	}
+	if len(req.Fields) == 0 && len(req.Update) == 0 && len(req.CustomFields) == 0 {
+		return errors.New("nothing to update, set fields, customFields and/or update")
+	}
+	for field, ops := range req.Update {
+		for _, op := range ops {
+			if len(op) != 1 {
+				return fmt.Errorf("update of %s must have exactly one operation per entry, e.g. {\"add\": ...}", field)
+			}
+		}
+	}
+	return nil
+}
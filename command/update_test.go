@@ -0,0 +1,128 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateIssue(t *testing.T) {
+	var method, path string
+	var body map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	input := []byte(`{
+		"issueId": "FLYTE-1",
+		"fields": {"summary": "New summary", "priority": {"name": "High"}, "duedate": "2026-11-01"},
+		"update": {"labels": [{"add": "triaged"}, {"remove": "new"}], "components": [{"set": [{"name": "API"}]}]}
+	}`)
+
+	actual := updateIssueHandler(singleInstanceRouter(t, c))(input)
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/rest/api/2/issue/FLYTE-1", path)
+	assert.Equal(t, map[string]interface{}{
+		"fields": map[string]interface{}{
+			"summary":  "New summary",
+			"priority": map[string]interface{}{"name": "High"},
+			"duedate":  "2026-11-01",
+		},
+		"update": map[string]interface{}{
+			"labels": []interface{}{
+				map[string]interface{}{"add": "triaged"},
+				map[string]interface{}{"remove": "new"},
+			},
+			"components": []interface{}{
+				map[string]interface{}{"set": []interface{}{map[string]interface{}{"name": "API"}}},
+			},
+		},
+	}, body)
+
+	expected := flyte.Event{
+		EventDef: issueUpdatedEventDef,
+		Payload: issueUpdatedPayload{
+			IssueId: "FLYTE-1",
+			Url:     c.Host() + "/browse/FLYTE-1",
+			IssueUpdate: client.IssueUpdate{
+				Fields: map[string]interface{}{
+					"summary":  "New summary",
+					"priority": map[string]interface{}{"name": "High"},
+					"duedate":  "2026-11-01",
+				},
+				Update: map[string][]client.FieldOperation{
+					"labels":     {{"add": "triaged"}, {"remove": "new"}},
+					"components": {{"set": []interface{}{map[string]interface{}{"name": "API"}}}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestUpdateIssueFieldErrors(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusBadRequest, map[string]interface{}{
+		"errorMessages": []string{},
+		"errors":        map[string]string{"duedate": "Error parsing date string: tomorrow"},
+	}))
+	input := []byte(`{"issueId": "FLYTE-1", "fields": {"duedate": "tomorrow"}}`)
+
+	actual := updateIssueHandler(r)(input)
+
+	expected := flyte.Event{
+		EventDef: issueUpdateFailureEventDef,
+		Payload: failurePayload{
+			Code:  "BAD_REQUEST",
+			Error: "Could not update issue: issueId=FLYTE-1 : statusCode=400 : duedate: Error parsing date string: tomorrow",
+			failureDetails: failureDetails{
+				StatusCode:  400,
+				FieldErrors: map[string]string{"duedate": "Error parsing date string: tomorrow"},
+			},
+			Input: json.RawMessage(input),
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestUpdateIssueInvalidInput(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s", r.URL.Path)
+	})
+
+	tests := map[string]string{
+		`{"fields": {"summary": "New summary"}}`:             "issueId is required",
+		`{"issueId": "FLYTE-1"}`:                             "nothing to update, set fields, customFields and/or update",
+		`{"issueId": "FLYTE-1", "update": {"labels": [{}]}}`: `update of labels must have exactly one operation per entry, e.g. {"add": ...}`,
+	}
+	for input, expectedError := range tests {
+		payload := updateIssueHandler(r)([]byte(input)).Payload.(failurePayload)
+		assert.Equal(t, "INVALID_INPUT", payload.Code, input)
+		assert.Equal(t, expectedError, payload.Error, input)
+	}
+
+	payload := updateIssueHandler(r)([]byte(`{"issueId": "FLYTE-1", "update": {"labels": "triaged"}}`)).Payload.(failurePayload)
+	assert.Equal(t, "INVALID_INPUT", payload.Code)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-jira/client"
+	"github.com/ExpediaGroup/flyte-jira/domain"
+	"log"
+)
+
+var (
+	userFoundEventDef = flyte.EventDef{
+		Name: "UserFound",
+	}
+
+	findUserFailureEventDef = flyte.EventDef{
+		Name: "FindUserFailure",
+	}
+)
+
+func FindUserCommand(r *client.Router) flyte.Command {
+	return flyte.Command{
+		Name:         "FindUser",
+		OutputEvents: []flyte.EventDef{userFoundEventDef, findUserFailureEventDef},
+		Handler:      findUserHandler(r),
+	}
+}
+
+type (
+	findUserRequest struct {
+		User     string `json:"user"`
+		IssueKey string `json:"issueKey,omitempty"`
+		instanceSelector
+	}
+
+	userFoundPayload struct {
+		User     string      `json:"user"`
+		IssueKey string      `json:"issueKey,omitempty"`
+		Found    userPayload `json:"found"`
+	}
+
+	userPayload struct {
+		Name        string `json:"name,omitempty"`
+		Key         string `json:"key,omitempty"`
+		AccountId   string `json:"accountId,omitempty"`
+		DisplayName string `json:"displayName"`
+		Email       string `json:"email,omitempty"`
+		Active      bool   `json:"active"`
+	}
+)
+
+// findUserHandler resolves a user by account id, name, email address, display
+// name or Slack handle, among the users who can be assigned issueKey when it
+// is given.
+func findUserHandler(r *client.Router) flyte.CommandHandler {
+	return func(input json.RawMessage) flyte.Event {
+		req := findUserRequest{}
+		if err := json.Unmarshal(input, &req); err != nil {
+			log.Printf("Error unmarshaling Find User Request [%s]: %s", input, err)
+			return newFailureEvent(findUserFailureEventDef, input, invalidInput(err))
+		}
+		if req.User == "" {
+			return newFailureEvent(findUserFailureEventDef, input, invalidInput(errors.New("user is required")))
+		}
+
+		c, err := r.Route(req.Instance, req.IssueKey)
+		if err != nil {
+			log.Printf("Error routing Find User Request for %s: %s", req.User, err)
+			return newFailureEvent(findUserFailureEventDef, input, err)
+		}
+
+		user, err := c.FindUser(req.User, req.IssueKey)
+		if err != nil {
+			err = fmt.Errorf("Could not find user: %w", err)
+			log.Println(err)
+			return newFailureEvent(findUserFailureEventDef, input, err)
+		}
+		return flyte.Event{
+			EventDef: userFoundEventDef,
+			Payload: userFoundPayload{
+				User:     req.User,
+				IssueKey: req.IssueKey,
+				Found:    newUserPayload(user),
+			},
+		}
+	}
+}
+
+func newUserPayload(user domain.User) userPayload {
+	return userPayload{
+		Name:        user.Name,
+		Key:         user.Key,
+		AccountId:   user.AccountID,
+		DisplayName: user.DisplayName,
+		Email:       user.EmailAddress,
+		Active:      user.Active,
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFindUserByEmail(t *testing.T) {
+	r := newTestRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/user/assignable/search" || r.URL.Query().Get("issueKey") != "FLYTE-1" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Write([]byte(`[{"name": "jdoe", "key": "JIRAUSER10100", "emailAddress": "jane.doe@example.com", "displayName": "Jane Doe", "active": true}]`))
+	})
+	input := []byte(`{"user": "@jane.doe", "issueKey": "FLYTE-1"}`)
+
+	actualEvent := findUserHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: userFoundEventDef,
+		Payload: userFoundPayload{
+			User:     "@jane.doe",
+			IssueKey: "FLYTE-1",
+			Found:    userPayload{Name: "jdoe", Key: "JIRAUSER10100", DisplayName: "Jane Doe", Email: "jane.doe@example.com", Active: true},
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestFindUserNotFound(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, []struct{}{}))
+	input := []byte(`{"user": "nobody@example.com"}`)
+
+	actualEvent := findUserHandler(r)(input)
+
+	expectedEvent := flyte.Event{
+		EventDef: findUserFailureEventDef,
+		Payload: failurePayload{
+			Code:  "INVALID_INPUT",
+			Error: `Could not find user: user not found: no user matches "nobody@example.com"`,
+			Input: json.RawMessage(input),
+		},
+	}
+	if !reflect.DeepEqual(actualEvent, expectedEvent) {
+		t.Errorf("Expected: %+v but got: %+v", expectedEvent, actualEvent)
+	}
+}
+
+func TestFindUserWithoutUser(t *testing.T) {
+	r := newTestRouter(t, respondWith(http.StatusOK, []struct{}{}))
+
+	payload := findUserHandler(r)([]byte(`{"issueKey": "FLYTE-1"}`)).Payload.(failurePayload)
+
+	if payload.Code != "INVALID_INPUT" || payload.Error != "user is required" {
+		t.Errorf("Unexpected failure: %+v", payload)
+	}
+}
@@ -18,7 +18,10 @@ package command
 
 import (
 	"encoding/json"
+	"github.com/ExpediaGroup/flyte-jira/client"
 	"github.com/ExpediaGroup/flyte-jira/domain"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -33,3 +36,47 @@ func toJson(i interface{}, t *testing.T) []byte {
 func createDummyIssue() domain.Issue {
 	return domain.Issue{}
 }
+
+// newTestClient returns a client talking to a local Jira stand-in that answers
+// every request with handler. The stand-in is shut down when the test ends.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *client.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := client.New(client.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	return c
+}
+
+// newTestRouter returns a router whose only instance is a client built by
+// newTestClient.
+func newTestRouter(t *testing.T, handler http.HandlerFunc) *client.Router {
+	return singleInstanceRouter(t, newTestClient(t, handler))
+}
+
+func singleInstanceRouter(t *testing.T, c *client.Client) *client.Router {
+	r, err := client.NewRouter("default", client.Instance{Name: "default", Client: c})
+	if err != nil {
+		t.Fatalf("error creating router: %v", err)
+	}
+	return r
+}
+
+// respondWith returns a handler that replies with status and body as JSON.
+func respondWith(status int, body interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if body != nil {
+			json.NewEncoder(w).Encode(body)
+		}
+	}
+}
+
+// roundTripFunc lets a plain function stand in for an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
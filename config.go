@@ -0,0 +1,271 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	jira "github.com/ExpediaGroup/flyte-jira/client"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultInstanceName = "default"
+
+// initializeRouter builds the Jira instances the pack talks to. With
+// JIRA_INSTANCES unset a single instance is read from JIRA_HOST, JIRA_USER
+// and JIRA_PASSWORD. Otherwise each listed instance reads the same settings
+// prefixed with its name, e.g. JIRA_ONPREM_HOST for the "onprem" instance.
+func initializeRouter() *jira.Router {
+	names := splitList(os.Getenv("JIRA_INSTANCES"))
+	if len(names) == 0 {
+		router, err := jira.NewRouter(defaultInstanceName, jira.Instance{
+			Name:   defaultInstanceName,
+			Client: newClient(defaultInstanceName, "JIRA_"),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return router
+	}
+
+	var instances []jira.Instance
+	for _, name := range names {
+		prefix := instanceEnvPrefix(name)
+		instances = append(instances, jira.Instance{
+			Name:     name,
+			Client:   newClient(name, prefix),
+			Projects: splitList(os.Getenv(prefix + "PROJECTS")),
+		})
+	}
+
+	defaultInstance := os.Getenv("JIRA_DEFAULT_INSTANCE")
+	if defaultInstance == "" {
+		defaultInstance = names[0]
+	}
+
+	router, err := jira.NewRouter(defaultInstance, instances...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return router
+}
+
+// newClient creates the client of an instance and detects whether it runs
+// on Jira Cloud, see jira.Client.DetectDeployment.
+func newClient(name, prefix string) *jira.Client {
+	c, err := jira.New(initializeConfig(name, prefix))
+	if err != nil {
+		log.Fatalf("cannot create client for %s jira instance: %v", name, err)
+	}
+	deployment, err := c.DetectDeployment()
+	if err != nil {
+		log.Printf("cannot detect the deployment of %s jira instance, set %sDEPLOYMENT to skip detection: %v", name, prefix, err)
+		return c
+	}
+	log.Printf("%s jira instance is a Jira %s deployment", name, deployment)
+	return c
+}
+
+func initializeConfig(name, prefix string) jira.Config {
+	return jira.Config{
+		Host: getEnv(prefix + "HOST"),
+		Auth: initializeAuth(name, prefix),
+		TLS: jira.TLSConfig{
+			InsecureSkipVerify: getBoolEnv(prefix + "INSECURE_SKIP_VERIFY"),
+			CAFile:             os.Getenv(prefix + "CA_FILE"),
+			CertFile:           os.Getenv(prefix + "CLIENT_CERT_FILE"),
+			KeyFile:            os.Getenv(prefix + "CLIENT_KEY_FILE"),
+		},
+		Retry:     initializeRetryConfig(prefix),
+		RateLimit: initializeRateLimitConfig(prefix),
+		Incident: jira.IncidentConfig{
+			Target:      strings.ToLower(os.Getenv(prefix + "INCIDENT_TARGET")),
+			Field:       os.Getenv(prefix + "INCIDENT_FIELD"),
+			URLTemplate: os.Getenv(prefix + "INCIDENT_URL_TEMPLATE"),
+		},
+		Deletion: jira.DeletionConfig{
+			Projects:      splitList(os.Getenv(prefix + "DELETE_PROJECTS")),
+			OwnIssuesOnly: getBoolEnv(prefix + "DELETE_OWN_ISSUES_ONLY"),
+		},
+		Deployment: strings.ToLower(os.Getenv(prefix + "DEPLOYMENT")),
+		BrowseURL:  os.Getenv(prefix + "BROWSE_URL"),
+	}
+}
+
+// initializeAuth sets up the credentials of an instance so that they can be
+// reloaded while the pack runs, see watchSecrets.
+func initializeAuth(name, prefix string) jira.Authenticator {
+	r := &authReloader{name: name, prefix: prefix}
+	auth, err := jira.NewReloadableAuth(r.load)
+	if err != nil {
+		log.Fatalf("cannot set up credentials for %s jira instance: %v", name, err)
+	}
+	r.auth = auth
+	authReloaders = append(authReloaders, r)
+	return auth
+}
+
+// loadAuth picks how the pack authenticates from AUTH_TYPE: "basic" (the
+// default) with USER and PASSWORD, "pat" with a Data Center personal access
+// TOKEN, "cloud" with a Jira Cloud EMAIL and API_TOKEN, "oauth2", "oauth1" or
+// "anonymous". Credentials may be read from files, see secretReader.
+func loadAuth(prefix string, s *secretReader) (jira.Authenticator, error) {
+	switch authType := strings.ToLower(os.Getenv(prefix + "AUTH_TYPE")); authType {
+	case "", "basic":
+		auth := jira.BasicAuth{Username: s.get(prefix + "USER"), Password: s.get(prefix + "PASSWORD")}
+		return auth, s.err
+	case "pat":
+		auth := jira.BearerToken{Token: s.get(prefix + "TOKEN")}
+		return auth, s.err
+	case "cloud":
+		auth := jira.APIToken{Email: s.get(prefix + "EMAIL"), Token: s.get(prefix + "API_TOKEN")}
+		return auth, s.err
+	case "oauth2":
+		config := jira.OAuth2Config{
+			TokenURL:         os.Getenv(prefix + "OAUTH_TOKEN_URL"),
+			ClientID:         s.get(prefix + "OAUTH_CLIENT_ID"),
+			ClientSecret:     s.get(prefix + "OAUTH_CLIENT_SECRET"),
+			RefreshTokenFile: getEnv(prefix + "OAUTH_REFRESH_TOKEN_FILE"),
+			// OAUTH_REFRESH_TOKEN_FILE is the token store above, not a secret file
+			RefreshToken:      os.Getenv(prefix + "OAUTH_REFRESH_TOKEN"),
+			AuthorizationCode: s.getOptional(prefix + "OAUTH_AUTHORIZATION_CODE"),
+			RedirectURI:       os.Getenv(prefix + "OAUTH_REDIRECT_URI"),
+			HTTPClient:        &http.Client{Timeout: 30 * time.Second},
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+		return jira.NewOAuth2(config)
+	case "oauth1":
+		keyFile := getEnv(prefix + "OAUTH_PRIVATE_KEY_FILE")
+		s.watch(keyFile)
+		key, err := jira.LoadRSAPrivateKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		auth := jira.OAuth1{
+			ConsumerKey: s.get(prefix + "OAUTH_CONSUMER_KEY"),
+			AccessToken: s.get(prefix + "OAUTH_ACCESS_TOKEN"),
+			PrivateKey:  key,
+		}
+		return auth, s.err
+	case "anonymous":
+		return jira.Anonymous{}, nil
+	default:
+		log.Fatalf("%sAUTH_TYPE env. variable has unsupported value: %s", prefix, authType)
+		return nil, nil
+	}
+}
+
+// initializeRetryConfig overrides the default retry settings with any of
+// RETRY_MAX, RETRY_BASE_DELAY, RETRY_MAX_DELAY and RETRY_TIMEOUT that are set.
+func initializeRetryConfig(prefix string) jira.RetryConfig {
+	config := jira.DefaultRetryConfig()
+	if os.Getenv(prefix+"RETRY_MAX") != "" {
+		config.MaxRetries = getIntEnv(prefix + "RETRY_MAX")
+	}
+	config.BaseDelay = getDurationEnv(prefix+"RETRY_BASE_DELAY", config.BaseDelay)
+	config.MaxDelay = getDurationEnv(prefix+"RETRY_MAX_DELAY", config.MaxDelay)
+	config.MaxElapsed = getDurationEnv(prefix+"RETRY_TIMEOUT", config.MaxElapsed)
+	return config
+}
+
+// instanceEnvPrefix turns an instance name into the prefix of its settings,
+// so "on-prem" is configured through JIRA_ON_PREM_HOST and friends.
+func instanceEnvPrefix(name string) string {
+	name = regexp.MustCompile(`[^A-Za-z0-9]+`).ReplaceAllString(name, "_")
+	return "JIRA_" + strings.ToUpper(name) + "_"
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnv(env string) string {
+	value := os.Getenv(env)
+	if value == "" {
+		log.Fatalf("%s env. variable is not set", env)
+	}
+	return value
+}
+
+// getBoolEnv reads an optional boolean setting, false when it is not set.
+func getBoolEnv(env string) bool {
+	value := os.Getenv(env)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s env. variable is not a valid boolean: %s", env, value)
+	}
+	return b
+}
+
+// initializeRateLimitConfig reads the optional local limits towards Jira:
+// RATE_LIMIT (requests per second), RATE_LIMIT_BURST, MAX_IN_FLIGHT and
+// RATE_LIMIT_MAX_WAIT.
+func initializeRateLimitConfig(prefix string) jira.RateLimitConfig {
+	config := jira.RateLimitConfig{MaxWait: 10 * time.Second}
+	if value := os.Getenv(prefix + "RATE_LIMIT"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			log.Fatalf("%sRATE_LIMIT env. variable is not a valid request rate: %s", prefix, value)
+		}
+		config.RequestsPerSecond = rate
+	}
+	config.Burst = getIntEnv(prefix + "RATE_LIMIT_BURST")
+	config.MaxInFlight = getIntEnv(prefix + "MAX_IN_FLIGHT")
+	config.MaxWait = getDurationEnv(prefix+"RATE_LIMIT_MAX_WAIT", config.MaxWait)
+	return config
+}
+
+// getIntEnv reads an optional non-negative number, 0 when it is not set.
+func getIntEnv(env string) int {
+	value := os.Getenv(env)
+	if value == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		log.Fatalf("%s env. variable is not a valid number: %s", env, value)
+	}
+	return i
+}
+
+// getDurationEnv reads an optional duration setting such as "500ms" or "1m".
+func getDurationEnv(env string, fallback time.Duration) time.Duration {
+	value := os.Getenv(env)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s env. variable is not a valid duration: %s", env, value)
+	}
+	return d
+}
@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import "encoding/json"
+
+type Comment struct {
+	ID         string      `json:"id"`
+	Self       string      `json:"self,omitempty"`
+	Author     User        `json:"author"`
+	Body       string      `json:"body"`
+	Created    string      `json:"created,omitempty"`
+	Updated    string      `json:"updated,omitempty"`
+	Visibility *Visibility `json:"visibility,omitempty"`
+}
+
+// UnmarshalJSON reads the body as sent by both the REST API v2 and v3, see
+// richText.
+func (c *Comment) UnmarshalJSON(b []byte) error {
+	type comment Comment
+	var raw struct {
+		comment
+		Body json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	body, err := richText(raw.Body)
+	if err != nil {
+		return err
+	}
+	*c = Comment(raw.comment)
+	c.Body = body
+	return nil
+}
+
+// Visibility restricts a comment to the members of a project role or of a
+// group.
+type Visibility struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,42 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-jira/markup"
+)
+
+// richText decodes a rich text field, which is wiki markup in the REST API v2
+// and an Atlassian Document Format document in v3, keeping the text of the
+// latter only.
+func richText(raw json.RawMessage) (string, error) {
+	var text string
+	switch {
+	case len(raw) == 0 || bytes.Equal(raw, []byte("null")):
+		return "", nil
+	case raw[0] == '"':
+		err := json.Unmarshal(raw, &text)
+		return text, err
+	}
+	var doc markup.Node
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "", err
+	}
+	return markup.PlainText(doc), nil
+}
@@ -16,9 +16,13 @@ limitations under the License.
 
 package domain
 
+import "encoding/json"
+
 type Issue struct {
 	Fields Fields `json:"fields"`
 	Key    string `json:"key"`
+	ID     string `json:"id,omitempty"`
+	Self   string `json:"self,omitempty"`
 }
 
 type Fields struct {
@@ -34,6 +38,26 @@ type Fields struct {
 	Type        IssueType   `json:"issuetype,omitempty" structs:"issuetype,omitempty"`
 }
 
+// UnmarshalJSON reads the description as sent by both the REST API v2 and v3,
+// see richText.
+func (f *Fields) UnmarshalJSON(b []byte) error {
+	type fields Fields
+	var raw struct {
+		fields
+		Description json.RawMessage `json:"description,omitempty"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	description, err := richText(raw.Description)
+	if err != nil {
+		return err
+	}
+	*f = Fields(raw.fields)
+	f.Description = description
+	return nil
+}
+
 type Assignee struct {
 	Self         string `json:"self"`
 	Name         string `json:"name"`
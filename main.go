@@ -19,16 +19,15 @@ package main
 import (
 	"github.com/ExpediaGroup/flyte-client/client"
 	"github.com/ExpediaGroup/flyte-client/flyte"
-	jira "github.com/ExpediaGroup/flyte-jira/client"
 	"github.com/ExpediaGroup/flyte-jira/command"
 	"log"
 	"net/url"
-	"os"
 	"time"
 )
 
 func main() {
-	jira.JiraConfig = initializeConfig()
+	router := initializeRouter()
+	watchSecrets()
 
 	hostUrl := getUrl(getEnv("FLYTE_API_URL"))
 
@@ -36,17 +35,29 @@ func main() {
 		Name:    "Jira",
 		HelpURL: getUrl("https://github.com/ExpediaGroup/flyte-jira/blob/master/README.md"),
 		Commands: []flyte.Command{
-			command.IssueInfoCommand,
-			command.CreateIssueCommand,
-			command.CreateIncIssueCommand,
-			command.IssueCommentCommand,
-			command.GetTransitions,
-			command.Transition,
-			command.SearchIssuesCommand,
-			command.IssueAssignCommand,
-			command.IssueCreateLinkCommand,
-			command.IssueGetLinkCommand,
-			command.IssueDeleteLinkCommand,
+			command.IssueInfoCommand(router),
+			command.CreateIssueCommand(router),
+			command.CreateIncIssueCommand(router),
+			command.BulkCreateIssuesCommand(router),
+			command.IssueCommentCommand(router),
+			command.ListCommentsCommand(router),
+			command.EditCommentCommand(router),
+			command.DeleteCommentCommand(router),
+			command.GetTransitions(router),
+			command.Transition(router),
+			command.SearchIssuesCommand(router),
+			command.IssueAssignCommand(router),
+			command.IssueCreateLinkCommand(router),
+			command.IssueGetLinkCommand(router),
+			command.IssueDeleteLinkCommand(router),
+			command.UpdateIssueCommand(router),
+			command.CreateSubtaskCommand(router),
+			command.GetChildIssuesCommand(router),
+			command.CloneIssueCommand(router),
+			command.DeleteIssueCommand(router),
+			command.ArchiveIssueCommand(router),
+			command.FindUserCommand(router),
+			command.FindIssuesByIncidentCommand(router),
 		},
 	}
 
@@ -56,22 +67,6 @@ func main() {
 	select {}
 }
 
-func initializeConfig() jira.Config {
-	return jira.Config{
-		getEnv("JIRA_HOST"),
-		getEnv("JIRA_USER"),
-		getEnv("JIRA_PASSWORD"),
-	}
-}
-
-func getEnv(env string) string {
-	value := os.Getenv(env)
-	if value == "" {
-		log.Fatalf("%s env. variable is not set", env)
-	}
-	return value
-}
-
 func getUrl(urlString string) *url.URL {
 	u, err := url.Parse(urlString)
 	if err != nil {
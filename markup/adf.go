@@ -0,0 +1,226 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package markup
+
+import (
+	"regexp"
+	"strings"
+)
+
+var blankLinesPattern = regexp.MustCompile(`\n\s*\n`)
+
+type (
+	// Node is a node of an Atlassian Document Format document, see
+	// https://developer.atlassian.com/cloud/jira/platform/apis/document/structure/
+	Node struct {
+		Type    string                 `json:"type"`
+		Version int                    `json:"version,omitempty"`
+		Attrs   map[string]interface{} `json:"attrs,omitempty"`
+		Content []Node                 `json:"content,omitempty"`
+		Text    string                 `json:"text,omitempty"`
+		Marks   []Mark                 `json:"marks,omitempty"`
+	}
+
+	// Mark formats a text node.
+	Mark struct {
+		Type  string                 `json:"type"`
+		Attrs map[string]interface{} `json:"attrs,omitempty"`
+	}
+)
+
+func newDocument(content []Node) *Node {
+	if len(content) == 0 {
+		content = []Node{{Type: "paragraph"}}
+	}
+	return &Node{Type: "doc", Version: 1, Content: content}
+}
+
+// plainADF returns the paragraphs of text, separated by blank lines.
+func plainADF(text string) []Node {
+	var nodes []Node
+	for _, paragraph := range blankLinesPattern.Split(strings.Replace(text, "\r\n", "\n", -1), -1) {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		var content []Node
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				content = append(content, Node{Type: "hardBreak"})
+			}
+			if line != "" {
+				content = append(content, Node{Type: "text", Text: line})
+			}
+		}
+		nodes = append(nodes, Node{Type: "paragraph", Content: content})
+	}
+	return nodes
+}
+
+func adfBlocks(blocks []block) []Node {
+	var nodes []Node
+	for _, block := range blocks {
+		nodes = append(nodes, adfBlock(block))
+	}
+	return nodes
+}
+
+func adfBlock(block block) Node {
+	switch block.kind {
+	case headingBlock:
+		return Node{Type: "heading", Attrs: map[string]interface{}{"level": block.level}, Content: adfInlines(block.inlines, nil)}
+	case codeBlock:
+		node := Node{Type: "codeBlock"}
+		if block.language != "" {
+			node.Attrs = map[string]interface{}{"language": block.language}
+		}
+		if block.code != "" {
+			node.Content = []Node{{Type: "text", Text: block.code}}
+		}
+		return node
+	case quoteBlock:
+		return Node{Type: "blockquote", Content: adfNested(block.blocks)}
+	case listBlock:
+		node := Node{Type: "bulletList"}
+		if block.ordered {
+			node.Type = "orderedList"
+		}
+		for _, item := range block.items {
+			node.Content = append(node.Content, Node{Type: "listItem", Content: adfNested(item)})
+		}
+		return node
+	case tableBlock:
+		table := Node{Type: "table", Content: []Node{adfRow(block.header, "tableHeader")}}
+		for _, row := range block.rows {
+			table.Content = append(table.Content, adfRow(row, "tableCell"))
+		}
+		return table
+	case ruleBlock:
+		return Node{Type: "rule"}
+	}
+	return Node{Type: "paragraph", Content: adfInlines(block.inlines, nil)}
+}
+
+// adfNested returns the content of quotes and list items, which cannot hold
+// headings, tables and rules and must not be empty.
+func adfNested(blocks []block) []Node {
+	var nodes []Node
+	for _, block := range blocks {
+		switch block.kind {
+		case headingBlock:
+			nodes = append(nodes, Node{Type: "paragraph", Content: adfInlines(block.inlines, []Mark{{Type: "strong"}})})
+		case tableBlock, ruleBlock:
+			continue
+		default:
+			nodes = append(nodes, adfBlock(block))
+		}
+	}
+	if len(nodes) == 0 {
+		nodes = []Node{{Type: "paragraph"}}
+	}
+	return nodes
+}
+
+func adfRow(cells [][]inline, cellType string) Node {
+	row := Node{Type: "tableRow"}
+	for _, cell := range cells {
+		paragraph := Node{Type: "paragraph", Content: adfInlines(cell, nil)}
+		row.Content = append(row.Content, Node{Type: cellType, Content: []Node{paragraph}})
+	}
+	return row
+}
+
+// adfInlines returns the text nodes of inlines, formatted with marks.
+func adfInlines(inlines []inline, marks []Mark) []Node {
+	var nodes []Node
+	for _, in := range inlines {
+		switch in.kind {
+		case textInline:
+			if in.text != "" {
+				nodes = append(nodes, Node{Type: "text", Text: in.text, Marks: marks})
+			}
+		case strongInline:
+			nodes = append(nodes, adfInlines(in.children, withMark(marks, Mark{Type: "strong"}))...)
+		case emInline:
+			nodes = append(nodes, adfInlines(in.children, withMark(marks, Mark{Type: "em"}))...)
+		case strikeInline:
+			nodes = append(nodes, adfInlines(in.children, withMark(marks, Mark{Type: "strike"}))...)
+		case linkInline:
+			link := Mark{Type: "link", Attrs: map[string]interface{}{"href": in.href}}
+			nodes = append(nodes, adfInlines(in.children, withMark(marks, link))...)
+		case codeInline:
+			if in.text == "" {
+				continue
+			}
+			// code can only be combined with links
+			code := []Mark{{Type: "code"}}
+			for _, mark := range marks {
+				if mark.Type == "link" {
+					code = append(code, mark)
+				}
+			}
+			nodes = append(nodes, Node{Type: "text", Text: in.text, Marks: code})
+		case breakInline:
+			nodes = append(nodes, Node{Type: "hardBreak"})
+		}
+	}
+	return nodes
+}
+
+// withMark returns marks with mark added, unless it is there already.
+func withMark(marks []Mark, mark Mark) []Mark {
+	for _, m := range marks {
+		if m.Type == mark.Type {
+			return marks
+		}
+	}
+	return append(marks[:len(marks):len(marks)], mark)
+}
+
+// PlainText returns the text of an Atlassian Document Format node, for
+// reading the rich text fields of the REST API v3. Paragraphs are separated
+// by blank lines, list items and table rows by newlines and table cells by
+// pipes.
+func PlainText(node Node) string {
+	switch node.Type {
+	case "text":
+		return node.Text
+	case "hardBreak":
+		return "\n"
+	case "mention", "emoji", "status", "date", "inlineCard":
+		for _, attr := range []string{"text", "shortName", "url"} {
+			if s, ok := node.Attrs[attr].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+
+	separator := "\n"
+	switch node.Type {
+	case "paragraph", "heading", "codeBlock":
+		separator = ""
+	case "doc", "blockquote", "listItem", "tableCell", "tableHeader", "panel", "expand":
+		separator = "\n\n"
+	case "tableRow":
+		separator = " | "
+	}
+	texts := make([]string, 0, len(node.Content))
+	for _, child := range node.Content {
+		texts = append(texts, PlainText(child))
+	}
+	return strings.Join(texts, separator)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package markup converts the rich text flows send, written in markdown or in
+// Slack mrkdwn, to the formats Jira renders: wiki markup for the REST API v2
+// and the Atlassian Document Format for the REST API v3.
+package markup
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The formats of the text given to ToWiki and ToADF.
+const (
+	// Wiki is Jira wiki markup, the format of the REST API v2.
+	Wiki = "wiki"
+	// Markdown is CommonMark with GitHub flavoured tables and strikethrough.
+	Markdown = "markdown"
+	// Slack is Slack mrkdwn, where *text* is bold and newlines break lines.
+	Slack = "mrkdwn"
+	// ADF is an Atlassian Document Format document in JSON, the format of
+	// the REST API v3.
+	ADF = "adf"
+)
+
+// CheckFormat returns an error unless format is one of Wiki, Markdown, Slack
+// and ADF, or empty, which stands for Wiki.
+func CheckFormat(format string) error {
+	switch format {
+	case "", Wiki, Markdown, Slack, ADF:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, use %s, %s, %s or %s", format, Wiki, Markdown, Slack, ADF)
+}
+
+// ToWiki converts text written in format to Jira wiki markup. Wiki text, and
+// text in an unknown format, is returned as is. Only the text of ADF
+// documents is kept.
+func ToWiki(text, format string) string {
+	switch format {
+	case Markdown, Slack:
+		return renderWiki(parse(text, format == Slack))
+	case ADF:
+		if doc, ok := parseADF(text); ok {
+			return escapeWiki(PlainText(*doc))
+		}
+	}
+	return text
+}
+
+// ToADF converts text written in format to an Atlassian Document Format
+// document. Wiki text, and text in an unknown format, is taken as plain text
+// whose blank lines separate paragraphs, as is ADF text that is not a
+// document.
+func ToADF(text, format string) *Node {
+	switch format {
+	case Markdown, Slack:
+		return newDocument(adfBlocks(parse(text, format == Slack)))
+	case ADF:
+		if doc, ok := parseADF(text); ok {
+			return doc
+		}
+	}
+	return newDocument(plainADF(text))
+}
+
+// parseADF decodes the ADF document text.
+func parseADF(text string) (*Node, bool) {
+	var doc Node
+	if err := json.Unmarshal([]byte(text), &doc); err != nil || doc.Type != "doc" {
+		return nil, false
+	}
+	return &doc, true
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package markup
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMarkdownToWiki(t *testing.T) {
+	for name, test := range map[string]struct {
+		markdown, wiki string
+	}{
+		"emphasis":         {"**bold** __bold__ *em* _em_ ~~struck~~ ***both***", "*bold* *bold* _em_ _em_ -struck- *_both_*"},
+		"intraword":        {"snake_case_name and 2*3*4", "snake_case_name and 2_3_4"},
+		"unclosed":         {"a * b and a_b", "a * b and a_b"},
+		"code span":        {"run `make *test*` or `` a`b ``", "run {{make \\*test*}} or {{a`b}}"},
+		"links":            {"see [the *docs*](https://example.com/docs \"Docs\") or <https://example.com>", "see [the _docs_|https://example.com/docs] or [https://example.com]"},
+		"email":            {"mail <ops@example.com>", "mail [ops@example.com|mailto:ops@example.com]"},
+		"image":            {"![graph](https://example.com/graph.png)", "[graph|https://example.com/graph.png]"},
+		"escapes":          {`\*not em\* and {braces} [brackets] a|b -flag- ~x~`, `\*not em* and \{braces\} \[brackets\] a\|b \-flag- \~x~`},
+		"line breaks":      {"soft\nbreak  \nhard\\\nbreak", "soft break\nhard\nbreak"},
+		"headings":         {"# Title\n### Sub *title* ###\n#hashtag", "h1. Title\n\nh3. Sub _title_\n\n#hashtag"},
+		"line starts":      {"\\# not a list\n\\- not a list", "\\# not a list - not a list"},
+		"code block":       {"```go\nfmt.Println(\"*x*\")\n```\n\n~~~\nplain {x}\n~~~", "{code:go}\nfmt.Println(\"*x*\")\n{code}\n\n{noformat}\nplain {x}\n{noformat}"},
+		"unknown language": {"```terraform\nresource {}\n```", "{noformat}\nresource {}\n{noformat}"},
+		"unclosed code":    {"```\ncode", "{noformat}\ncode\n{noformat}"},
+		"quote":            {"> quoted **text**\n> more\n>\n> - item", "{quote}\nquoted *text* more\n\n* item\n{quote}"},
+		"lists": {
+			"- first\n- second\n  continued\n  - nested\n    1. deep\n- third\nlazy",
+			"* first\n* second continued\n** nested\n**# deep\n* third lazy",
+		},
+		"loose list":     {"1. one\n\n2. two\n\n   more\n\nafter", "# one\n# two \\\\ more\n\nafter"},
+		"list kinds":     {"- bullet\n1. ordered", "* bullet\n\n# ordered"},
+		"list with code": {"- step\n\n  ```\n  make\n  ```", "* step\n{noformat}\nmake\n{noformat}"},
+		"rule":           {"above\n\n---\n\n* * *", "above\n\n----\n\n----"},
+		"table": {
+			"| Service | Status |\n|---|:---:|\n| api | **down** |\n| db |\n| a \\| b | c | extra |",
+			"||Service||Status||\n|api|*down*|\n|db| |\n|a \\| b|c|",
+		},
+		"entities":  {"a &amp; b &lt;c&gt;", "a & b <c>"},
+		"windows":   {"a\r\nb", "a b"},
+		"empty":     {"", ""},
+		"not table": {"a | b\nc", "a \\| b c"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.wiki, ToWiki(test.markdown, Markdown))
+		})
+	}
+}
+
+func TestSlackToWiki(t *testing.T) {
+	for name, test := range map[string]struct {
+		mrkdwn, wiki string
+	}{
+		"emphasis":    {"*bold* _em_ ~struck~ *_both_*", "*bold* _em_ -struck- *_both_*"},
+		"intraword":   {"snake_case_name a*b*c", "snake_case_name a*b*c"},
+		"line breaks": {"first\nsecond", "first\nsecond"},
+		"links":       {"<https://example.com/a?b=1&amp;c=2|the *dashboard*> <https://example.com>", "[the \\*dashboard*|https://example.com/a?b=1&c=2] [https://example.com]"},
+		"mentions":    {"<@U123> <@U123|jdoe> <#C123|ops> <!here> <!subteam^S123|@oncall>", "@U123 @jdoe #ops @here @oncall"},
+		"entities":    {"a &amp; b &lt;c&gt;", "a & b <c>"},
+		"code":        {"run `make test` or ```make all```", "run {{make test}} or {{make all}}"},
+		"code block":  {"```first\nsecond```\nafter", "{noformat}\nfirst\nsecond\n{noformat}\n\nafter"},
+		"lists":       {"• one\n• two\n- three", "* one\n* two\n* three"},
+		"quote":       {"> one\n> two\nafter", "{quote}\none\ntwo\n{quote}\n\nafter"},
+		"long quote":  {"before\n>>> one\n\ntwo", "before\n\n{quote}\none\n\ntwo\n{quote}"},
+		"markdown":    {"**not bold** [not](link)\n# not a heading\n| not | a |\n| --- | --- |", "*not bold* \\[not\\](link)\n\\# not a heading\n\\| not \\| a \\|\n\\| \\--- \\| \\--- \\|"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.wiki, ToWiki(test.mrkdwn, Slack))
+		})
+	}
+}
+
+func TestWikiIsKept(t *testing.T) {
+	assert.Equal(t, "h1. *Title* {code}x{code}", ToWiki("h1. *Title* {code}x{code}", Wiki))
+	assert.Equal(t, "h1. *Title*", ToWiki("h1. *Title*", ""))
+}
+
+func TestToADF(t *testing.T) {
+	for name, test := range map[string]struct {
+		text, format, adf string
+	}{
+		"marks": {
+			"**bold** _em_ ~~struck~~ [`code` link](https://example.com)", Markdown,
+			`[{"type":"paragraph","content":[
+				{"type":"text","text":"bold","marks":[{"type":"strong"}]},{"type":"text","text":" "},
+				{"type":"text","text":"em","marks":[{"type":"em"}]},{"type":"text","text":" "},
+				{"type":"text","text":"struck","marks":[{"type":"strike"}]},{"type":"text","text":" "},
+				{"type":"text","text":"code","marks":[{"type":"code"},{"type":"link","attrs":{"href":"https://example.com"}}]},
+				{"type":"text","text":" link","marks":[{"type":"link","attrs":{"href":"https://example.com"}}]}]}]`,
+		},
+		"nested marks": {
+			"*bold _and em_*", Slack,
+			`[{"type":"paragraph","content":[
+				{"type":"text","text":"bold ","marks":[{"type":"strong"}]},
+				{"type":"text","text":"and em","marks":[{"type":"strong"},{"type":"em"}]}]}]`,
+		},
+		"blocks": {
+			"## Title\n\nline\nbreak\n\n```sh\nmake\n```\n\n> quote\n\n---", Slack,
+			`[{"type":"paragraph","content":[{"type":"text","text":"## Title"}]},
+				{"type":"paragraph","content":[{"type":"text","text":"line"},{"type":"hardBreak"},{"type":"text","text":"break"}]},
+				{"type":"codeBlock","content":[{"type":"text","text":"sh\nmake"}]},
+				{"type":"blockquote","content":[{"type":"paragraph","content":[{"type":"text","text":"quote"}]}]},
+				{"type":"paragraph","content":[{"type":"text","text":"---"}]}]`,
+		},
+		"markdown blocks": {
+			"## Title\n\n```sh\nmake\n```\n\n> # quoted title", Markdown,
+			`[{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Title"}]},
+				{"type":"codeBlock","attrs":{"language":"sh"},"content":[{"type":"text","text":"make"}]},
+				{"type":"blockquote","content":[{"type":"paragraph","content":[{"type":"text","text":"quoted title","marks":[{"type":"strong"}]}]}]}]`,
+		},
+		"lists": {
+			"1. one\n   - nested\n2.", Markdown,
+			`[{"type":"orderedList","content":[
+				{"type":"listItem","content":[
+					{"type":"paragraph","content":[{"type":"text","text":"one"}]},
+					{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"nested"}]}]}]}]},
+				{"type":"listItem","content":[{"type":"paragraph"}]}]}]`,
+		},
+		"table": {
+			"| a | b |\n| - | - |\n| 1 |", Markdown,
+			`[{"type":"table","content":[
+				{"type":"tableRow","content":[
+					{"type":"tableHeader","content":[{"type":"paragraph","content":[{"type":"text","text":"a"}]}]},
+					{"type":"tableHeader","content":[{"type":"paragraph","content":[{"type":"text","text":"b"}]}]}]},
+				{"type":"tableRow","content":[
+					{"type":"tableCell","content":[{"type":"paragraph","content":[{"type":"text","text":"1"}]}]},
+					{"type":"tableCell","content":[{"type":"paragraph"}]}]}]}]`,
+		},
+		"wiki": {
+			"*first* line\nsecond line\n\n\nnext", Wiki,
+			`[{"type":"paragraph","content":[{"type":"text","text":"*first* line"},{"type":"hardBreak"},{"type":"text","text":"second line"}]},
+				{"type":"paragraph","content":[{"type":"text","text":"next"}]}]`,
+		},
+		"empty": {"", Markdown, `[{"type":"paragraph"}]`},
+		"adf": {
+			`{"type":"doc","version":1,"content":[{"type":"rule"}]}`, ADF,
+			`[{"type":"rule"}]`,
+		},
+		"invalid adf": {"{not json", ADF, `[{"type":"paragraph","content":[{"type":"text","text":"{not json"}]}]`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc := ToADF(test.text, test.format)
+
+			require.Equal(t, "doc", doc.Type)
+			assert.Equal(t, 1, doc.Version)
+			actual, err := json.Marshal(doc.Content)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.adf, string(actual))
+		})
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	for _, format := range []string{"", Wiki, Markdown, Slack, ADF} {
+		assert.NoError(t, CheckFormat(format))
+	}
+	assert.EqualError(t, CheckFormat("html"), `unknown format "html", use wiki, markdown, mrkdwn or adf`)
+}
+
+func TestPlainText(t *testing.T) {
+	doc := ToADF("# Title\n\nsome **bold** text\nand a [link](https://example.com)\n\n- one\n- two\n\n| a | b |\n| - | - |\n| 1 | 2 |", Markdown)
+	doc.Content = append(doc.Content, Node{Type: "paragraph", Content: []Node{
+		{Type: "mention", Attrs: map[string]interface{}{"id": "5b10ac8d82e05b22cc7d4ef5", "text": "@Jane Doe"}},
+		{Type: "text", Text: " please check"},
+	}})
+
+	assert.Equal(t, "Title\n\nsome bold text and a link\n\none\ntwo\n\na | b\n1 | 2\n\n@Jane Doe please check", PlainText(*doc))
+}
+
+func TestADFToWiki(t *testing.T) {
+	adf := `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"see {this}","marks":[{"type":"strong"}]}]}]}`
+
+	assert.Equal(t, `see \{this\}`, ToWiki(adf, ADF))
+	assert.Equal(t, "{not json", ToWiki("{not json", ADF))
+}
@@ -0,0 +1,619 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package markup
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	headingBlock
+	codeBlock
+	quoteBlock
+	listBlock
+	tableBlock
+	ruleBlock
+)
+
+// block is a paragraph, heading, code block, quote, list, table or rule of
+// the parsed text.
+type block struct {
+	kind blockKind
+	// inlines is the text of paragraphs and headings.
+	inlines []inline
+	// level is the level of headings, from 1 to 6.
+	level    int
+	language string
+	code     string
+	// blocks is the content of quotes.
+	blocks  []block
+	ordered bool
+	// items are the contents of the list items.
+	items  [][]block
+	header [][]inline
+	rows   [][][]inline
+}
+
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	strongInline
+	emInline
+	strikeInline
+	codeInline
+	linkInline
+	breakInline
+)
+
+// inline is a piece of text, a line break or a run of formatted text.
+type inline struct {
+	kind inlineKind
+	// text is the text of text and code inlines.
+	text     string
+	href     string
+	children []inline
+}
+
+var (
+	fencePattern        = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	headingPattern      = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	rulePattern         = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	listPattern         = regexp.MustCompile(`^( *)([-*+•]|\d{1,9}[.)])(?: +(.*)|$)`)
+	quotePattern        = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	delimiterRowPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	schemePattern       = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*$`)
+	emailPattern        = regexp.MustCompile(`^[^\s<>@]+@[^\s<>@]+\.[^\s<>@]+$`)
+)
+
+// parser parses markdown, or Slack mrkdwn when slack is set.
+type parser struct {
+	slack bool
+}
+
+func parse(text string, slack bool) []block {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+	return parser{slack: slack}.blocks(lines)
+}
+
+// expandTabs replaces the tabs indenting line with 4 spaces.
+func expandTabs(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	return strings.Replace(line[:indent], "\t", "    ", -1) + line[indent:]
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func (p parser) blocks(lines []string) []block {
+	var blocks []block
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, block{kind: paragraphBlock, inlines: p.inlines(p.joinLines(paragraph))})
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		var b block
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			continue
+		case p.isFence(line):
+			b, i = p.code(lines, i)
+		case !p.slack && headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			b = block{kind: headingBlock, level: len(m[1]), inlines: p.inlines(m[2])}
+		case !p.slack && rulePattern.MatchString(line):
+			b = block{kind: ruleBlock}
+		case quotePattern.MatchString(line):
+			b, i = p.quote(lines, i)
+		case listPattern.MatchString(line) && !rulePattern.MatchString(line):
+			b, i = p.list(lines, i)
+		case !p.slack && i+1 < len(lines) && p.isTable(line, lines[i+1]):
+			b, i = p.table(lines, i)
+		default:
+			paragraph = append(paragraph, line)
+			continue
+		}
+		flush()
+		blocks = append(blocks, b)
+	}
+	flush()
+	return blocks
+}
+
+// joinLines joins the lines of a paragraph, with a newline where the line
+// breaks: at every line in Slack and after lines ending with two spaces or a
+// backslash in markdown, where other lines are joined with a space.
+func (p parser) joinLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if i == len(lines)-1 {
+			b.WriteString(trimmed)
+			break
+		}
+		switch {
+		case p.slack || strings.HasSuffix(line, "  "):
+			b.WriteString(trimmed + "\n")
+		case strings.HasSuffix(trimmed, `\`) && !strings.HasSuffix(trimmed, `\\`):
+			b.WriteString(strings.TrimSuffix(trimmed, `\`) + "\n")
+		default:
+			b.WriteString(trimmed + " ")
+		}
+	}
+	return b.String()
+}
+
+func (p parser) isFence(line string) bool {
+	m := fencePattern.FindStringSubmatch(line)
+	// a markdown info string cannot hold backticks, ```code``` is inline
+	return m != nil && (p.slack || m[1][0] == '~' || !strings.Contains(m[2], "`"))
+}
+
+// code parses the code block opened at lines[i] and returns it with the index
+// of its last line. In Slack the code starts right after the fence, which may
+// be closed on the same line, and in markdown the fence is followed by the
+// language.
+func (p parser) code(lines []string, i int) (block, int) {
+	m := fencePattern.FindStringSubmatch(lines[i])
+	fence := m[1]
+	b := block{kind: codeBlock}
+	var code []string
+	if p.slack {
+		if end := strings.Index(m[2], fence); end >= 0 {
+			b.code = m[2][:end]
+			return b, i
+		}
+		if m[2] != "" {
+			code = append(code, m[2])
+		}
+	} else if fields := strings.Fields(m[2]); len(fields) > 0 {
+		b.language = fields[0]
+	}
+
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if p.slack {
+			if end := strings.Index(line, fence); end >= 0 {
+				if line[:end] != "" {
+					code = append(code, line[:end])
+				}
+				break
+			}
+		} else if strings.HasPrefix(strings.TrimSpace(line), fence) && strings.Trim(strings.TrimSpace(line), fence[:1]) == "" {
+			break
+		}
+		code = append(code, line)
+	}
+	b.code = strings.Join(code, "\n")
+	return b, i
+}
+
+// quote parses the quote starting at lines[i] and returns it with the index of
+// its last line. A Slack quote opened with >>> goes on to the end of the text.
+func (p parser) quote(lines []string, i int) (block, int) {
+	if trimmed := strings.TrimSpace(lines[i]); p.slack && strings.HasPrefix(trimmed, ">>>") {
+		quoted := append([]string{strings.TrimSpace(strings.TrimPrefix(trimmed, ">>>"))}, lines[i+1:]...)
+		return block{kind: quoteBlock, blocks: p.blocks(quoted)}, len(lines) - 1
+	}
+
+	var quoted []string
+	for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+		quoted = append(quoted, quotePattern.FindStringSubmatch(lines[i])[1])
+	}
+	return block{kind: quoteBlock, blocks: p.blocks(quoted)}, i - 1
+}
+
+// list parses the list starting at lines[i] and returns it with the index of
+// its last line. The lines indented deeper than the markers belong to the
+// items, whose content is parsed as blocks, making nested lists.
+func (p parser) list(lines []string, i int) (block, int) {
+	m := listPattern.FindStringSubmatch(lines[i])
+	indent := len(m[1])
+	b := block{kind: listBlock, ordered: isOrdered(m[2])}
+
+	var item []string
+	contentIndent := 0
+	flush := func() {
+		if item != nil {
+			b.items = append(b.items, p.blocks(item))
+		}
+	}
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := listPattern.FindStringSubmatch(line); m != nil && len(m[1]) <= indent && !rulePattern.MatchString(line) {
+			if isOrdered(m[2]) != b.ordered {
+				break
+			}
+			flush()
+			item = []string{m[3]}
+			contentIndent = len(m[1]) + utf8.RuneCountInString(m[2]) + 1
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// the list goes on after blank lines if the next line is
+			// indented or is another item
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next == len(lines) {
+				break
+			}
+			if n := listPattern.FindStringSubmatch(lines[next]); indentation(lines[next]) <= indent && (n == nil || isOrdered(n[2]) != b.ordered) {
+				break
+			}
+			item = append(item, "")
+			continue
+		}
+		if spaces := indentation(line); spaces > indent {
+			if spaces > contentIndent {
+				spaces = contentIndent
+			}
+			item = append(item, line[spaces:])
+			continue
+		}
+		// a line following the text of an item continues it
+		if last := item[len(item)-1]; strings.TrimSpace(last) != "" && !p.startsBlock(line) {
+			item = append(item, line)
+			continue
+		}
+		break
+	}
+	flush()
+	return b, i - 1
+}
+
+func isOrdered(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// startsBlock tells whether line starts a block other than a paragraph.
+func (p parser) startsBlock(line string) bool {
+	return p.isFence(line) || !p.slack && (headingPattern.MatchString(line) || rulePattern.MatchString(line)) ||
+		quotePattern.MatchString(line) || listPattern.MatchString(line)
+}
+
+// isTable tells whether line is the header of a table, followed by the row
+// delimiting it.
+func (p parser) isTable(line, next string) bool {
+	return strings.Contains(line, "|") && strings.Contains(next, "-") && delimiterRowPattern.MatchString(next)
+}
+
+// table parses the table whose header is lines[i] and returns it with the
+// index of its last line.
+func (p parser) table(lines []string, i int) (block, int) {
+	b := block{kind: tableBlock, header: p.cells(lines[i], -1)}
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		b.rows = append(b.rows, p.cells(lines[i], len(b.header)))
+	}
+	return b, i - 1
+}
+
+// cells splits a row of a table in count cells, as many as there are when
+// count is negative.
+func (p parser) cells(line string, count int) [][]inline {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells [][]inline
+	start := 0
+	for i := 0; i <= len(line); i++ {
+		if i == len(line) || line[i] == '|' && (i == 0 || line[i-1] != '\\') {
+			cell := strings.Replace(line[start:i], `\|`, "|", -1)
+			cells = append(cells, p.inlines(strings.TrimSpace(cell)))
+			start = i + 1
+		}
+	}
+	if count < 0 {
+		return cells
+	}
+	for len(cells) < count {
+		cells = append(cells, nil)
+	}
+	return cells[:count]
+}
+
+// delimiters returns the delimiters of strong, emphasised and struck text, the
+// longest first.
+func (p parser) delimiters() []string {
+	if p.slack {
+		return []string{"*", "_", "~"}
+	}
+	return []string{"**", "__", "~~", "*", "_"}
+}
+
+func (p parser) delimiterKind(delimiter string) inlineKind {
+	switch {
+	case delimiter[0] == '~':
+		return strikeInline
+	case p.slack && delimiter == "*", len(delimiter) == 2:
+		return strongInline
+	}
+	return emInline
+}
+
+// intraword tells whether delimiter may open or close in the middle of a word,
+// e.g. in snake_case it does not.
+func (p parser) intraword(delimiter string) bool {
+	return !p.slack && delimiter[0] == '*'
+}
+
+// inlines parses the text of a paragraph, in which newlines break lines.
+func (p parser) inlines(text string) []inline {
+	var inlines []inline
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			inlines = append(inlines, inline{kind: textInline, text: html.UnescapeString(buf.String())})
+			buf.Reset()
+		}
+	}
+	add := func(in inline, length int) int {
+		flush()
+		inlines = append(inlines, in)
+		return length
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && !p.slack && i+1 < len(text) && isASCIIPunct(text[i+1]):
+			buf.WriteByte(text[i+1])
+			i += 2
+			continue
+		case c == '\n':
+			i += add(inline{kind: breakInline}, 1)
+			continue
+		case c == '`':
+			code, n := codeSpan(text[i:])
+			if n == 0 {
+				// an unclosed run of backticks is text
+				n = len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+				buf.WriteString(text[i : i+n])
+				i += n
+				continue
+			}
+			i += add(inline{kind: codeInline, text: code}, n)
+			continue
+		case c == '!' && !p.slack && strings.HasPrefix(text[i+1:], "["):
+			if label, href, n := markdownLink(text[i+1:]); n > 0 {
+				if label == "" {
+					label = href
+				}
+				i += add(inline{kind: linkInline, href: href, children: p.inlines(label)}, n+1)
+				continue
+			}
+		case c == '[' && !p.slack:
+			if label, href, n := markdownLink(text[i:]); n > 0 {
+				i += add(inline{kind: linkInline, href: href, children: p.inlines(label)}, n)
+				continue
+			}
+		case c == '<':
+			if in, n := p.angle(text[i:]); n > 0 {
+				i += add(in, n)
+				continue
+			}
+		default:
+			if delimiter, end := p.emphasis(text, i); end > 0 {
+				kind := p.delimiterKind(delimiter)
+				children := p.inlines(text[i+len(delimiter) : end])
+				if len(children) == 1 && children[0].kind == kind {
+					// **text** in Slack is bold once
+					children = children[0].children
+				}
+				i += add(inline{kind: kind, children: children}, end+len(delimiter)-i)
+				continue
+			}
+		}
+		buf.WriteByte(c)
+		i++
+	}
+	flush()
+	return inlines
+}
+
+// emphasis returns the delimiter opening strong, emphasised or struck text at
+// text[i] and the index of the delimiter closing it, or a zero index when
+// there is none.
+func (p parser) emphasis(text string, i int) (string, int) {
+	for _, delimiter := range p.delimiters() {
+		start := i + len(delimiter)
+		if !strings.HasPrefix(text[i:], delimiter) || start >= len(text) || isSpace(text[start]) {
+			continue
+		}
+		if !p.intraword(delimiter) && i > 0 && isWordByte(text[i-1]) {
+			continue
+		}
+		if end := p.closing(text, start, delimiter); end > 0 {
+			return delimiter, end
+		}
+	}
+	return "", 0
+}
+
+// closing returns the index of the delimiter closing text opened before start,
+// or 0 if the text is not closed on the same line.
+func (p parser) closing(text string, start int, delimiter string) int {
+	for j := start + 1; j < len(text); {
+		switch text[j] {
+		case '\n':
+			return 0
+		case '`':
+			if _, n := codeSpan(text[j:]); n > 0 {
+				j += n
+				continue
+			}
+		case delimiter[0]:
+			run := len(text[j:]) - len(strings.TrimLeft(text[j:], delimiter[:1]))
+			if run < len(delimiter) || len(delimiter) == 1 && run > 1 && !p.slack {
+				// ** does not close *, a single * does not close **
+				j += run
+				continue
+			}
+			end := j + run - len(delimiter)
+			after := end + len(delimiter)
+			if !isSpace(text[j-1]) && (p.intraword(delimiter) || after == len(text) || !isWordByte(text[after])) {
+				return end
+			}
+			j += run
+			continue
+		}
+		j++
+	}
+	return 0
+}
+
+// codeSpan returns the code of the code span text starts with and its length,
+// which is 0 if text does not start with a code span.
+func codeSpan(text string) (string, int) {
+	fence := text[:len(text)-len(strings.TrimLeft(text, "`"))]
+	for j := len(fence); j < len(text); {
+		k := strings.Index(text[j:], fence)
+		if k < 0 {
+			return "", 0
+		}
+		k += j
+		end := k + len(fence)
+		if end < len(text) && text[end] == '`' {
+			// a longer run of backticks does not close the span
+			j = end + len(text[end:]) - len(strings.TrimLeft(text[end:], "`"))
+			continue
+		}
+		code := text[len(fence):k]
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		return code, end
+	}
+	return "", 0
+}
+
+// markdownLink parses the [label](url "title") link text starts with and
+// returns its label, url and length, which is 0 if text does not start with a
+// link.
+func markdownLink(text string) (string, string, int) {
+	closeLabel := matching(text, '[', ']')
+	if closeLabel < 0 || closeLabel+1 >= len(text) || text[closeLabel+1] != '(' {
+		return "", "", 0
+	}
+	closeURL := matching(text[closeLabel+1:], '(', ')')
+	if closeURL < 0 {
+		return "", "", 0
+	}
+	target := strings.TrimSpace(text[closeLabel+2 : closeLabel+1+closeURL])
+	if fields := strings.Fields(target); len(fields) > 0 {
+		target = fields[0]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return text[1:closeLabel], target, closeLabel + 2 + closeURL
+}
+
+// matching returns the index of the close character matching the open one text
+// starts with, or -1.
+func matching(text string, open, close byte) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case open:
+			depth++
+		case close:
+			if depth--; depth == 0 {
+				return i
+			}
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// angle parses the <...> text starts with: an autolink in markdown, a link,
+// a user or channel mention or a special mention such as <!here> in Slack. It
+// returns the length 0 if text does not start with any of them.
+func (p parser) angle(text string) (inline, int) {
+	end := strings.IndexAny(text, ">\n")
+	if end < 0 || text[end] != '>' {
+		return inline{}, 0
+	}
+	content := text[1:end]
+	if p.slack {
+		target, label := content, ""
+		if bar := strings.Index(content, "|"); bar >= 0 {
+			target, label = content[:bar], content[bar+1:]
+		}
+		switch {
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				target = target[:1] + label
+			}
+			return inline{kind: textInline, text: target}, end + 1
+		case strings.HasPrefix(target, "!"):
+			if label == "" {
+				label = "@" + strings.SplitN(target[1:], "^", 2)[0]
+			}
+			return inline{kind: textInline, text: label}, end + 1
+		case schemePattern.MatchString(target):
+			if label == "" {
+				label = target
+			}
+			return inline{kind: linkInline, href: html.UnescapeString(target), children: []inline{{kind: textInline, text: html.UnescapeString(label)}}}, end + 1
+		}
+		return inline{}, 0
+	}
+
+	switch {
+	case schemePattern.MatchString(content):
+		return inline{kind: linkInline, href: content, children: []inline{{kind: textInline, text: content}}}, end + 1
+	case emailPattern.MatchString(content):
+		return inline{kind: linkInline, href: "mailto:" + content, children: []inline{{kind: textInline, text: content}}}, end + 1
+	}
+	return inline{}, 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < 0x80 && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
@@ -0,0 +1,188 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package markup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wikiLanguages are the languages {code} blocks highlight, other code is put
+// in {noformat} blocks rather than highlighted as Java.
+var wikiLanguages = map[string]bool{
+	"actionscript": true, "ada": true, "applescript": true, "bash": true, "c": true, "c#": true, "c++": true,
+	"cpp": true, "css": true, "erlang": true, "go": true, "groovy": true, "haskell": true, "html": true,
+	"java": true, "javascript": true, "js": true, "json": true, "lua": true, "objc": true, "perl": true,
+	"php": true, "python": true, "r": true, "ruby": true, "scala": true, "sh": true, "sql": true,
+	"swift": true, "visualbasic": true, "xml": true, "yaml": true,
+}
+
+// wikiLineStartPattern matches the starts of lines that wiki markup takes for
+// lists, headings or quotes.
+var wikiLineStartPattern = regexp.MustCompile(`^(?:[*#-]+ |h[1-6]\. |bq\. )`)
+
+func renderWiki(blocks []block) string {
+	var b strings.Builder
+	writeWikiBlocks(&b, blocks)
+	return b.String()
+}
+
+func writeWikiBlocks(b *strings.Builder, blocks []block) {
+	for i, block := range blocks {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		writeWikiBlock(b, block)
+	}
+}
+
+func writeWikiBlock(b *strings.Builder, block block) {
+	switch block.kind {
+	case paragraphBlock:
+		lines := strings.Split(wikiInlines(block.inlines, false), "\n")
+		for i, line := range lines {
+			if wikiLineStartPattern.MatchString(line) {
+				lines[i] = `\` + line
+			}
+		}
+		b.WriteString(strings.Join(lines, "\n"))
+	case headingBlock:
+		b.WriteString("h" + string(rune('0'+block.level)) + ". " + wikiInlines(block.inlines, true))
+	case codeBlock:
+		language := strings.ToLower(block.language)
+		if wikiLanguages[language] {
+			b.WriteString("{code:" + language + "}\n" + block.code + "\n{code}")
+		} else {
+			b.WriteString("{noformat}\n" + block.code + "\n{noformat}")
+		}
+	case quoteBlock:
+		b.WriteString("{quote}\n")
+		writeWikiBlocks(b, block.blocks)
+		b.WriteString("\n{quote}")
+	case listBlock:
+		writeWikiList(b, block, "")
+	case tableBlock:
+		writeWikiRow(b, block.header, "||")
+		for _, row := range block.rows {
+			b.WriteString("\n")
+			writeWikiRow(b, row, "|")
+		}
+	case ruleBlock:
+		b.WriteString("----")
+	}
+}
+
+// writeWikiList writes the items of list prefixed with the markers of the
+// lists it is nested in. The paragraphs of an item are separated by line
+// breaks, as wiki list items hold a single line.
+func writeWikiList(b *strings.Builder, list block, markers string) {
+	if list.ordered {
+		markers += "#"
+	} else {
+		markers += "*"
+	}
+	for i, item := range list.items {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(markers + " ")
+		var text []string
+		for _, block := range item {
+			switch block.kind {
+			case paragraphBlock, headingBlock:
+				text = append(text, wikiInlines(block.inlines, true))
+				continue
+			}
+			b.WriteString(strings.Join(text, ` \\ `))
+			text = nil
+			b.WriteString("\n")
+			if block.kind == listBlock {
+				writeWikiList(b, block, markers)
+			} else {
+				writeWikiBlock(b, block)
+			}
+		}
+		b.WriteString(strings.Join(text, ` \\ `))
+	}
+}
+
+func writeWikiRow(b *strings.Builder, cells [][]inline, separator string) {
+	for _, cell := range cells {
+		text := wikiInlines(cell, true)
+		if text == "" {
+			// empty cells would merge with the next one
+			text = " "
+		}
+		b.WriteString(separator + text)
+	}
+	b.WriteString(separator)
+}
+
+// wikiInlines renders inlines, with line breaks as \\ when compact is set,
+// for table cells and list items, which hold a single line.
+func wikiInlines(inlines []inline, compact bool) string {
+	var b strings.Builder
+	for _, in := range inlines {
+		switch in.kind {
+		case textInline:
+			b.WriteString(escapeWiki(in.text))
+		case strongInline:
+			b.WriteString("*" + wikiInlines(in.children, compact) + "*")
+		case emInline:
+			b.WriteString("_" + wikiInlines(in.children, compact) + "_")
+		case strikeInline:
+			b.WriteString("-" + wikiInlines(in.children, compact) + "-")
+		case codeInline:
+			b.WriteString("{{" + escapeWiki(in.text) + "}}")
+		case linkInline:
+			label := wikiInlines(in.children, compact)
+			if label == "" || label == escapeWiki(in.href) {
+				b.WriteString("[" + in.href + "]")
+			} else {
+				b.WriteString("[" + label + "|" + in.href + "]")
+			}
+		case breakInline:
+			if compact {
+				b.WriteString(` \\ `)
+			} else {
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// escapeWiki escapes the characters of text that wiki markup would take for
+// markup: braces, brackets and pipes, and the characters formatting text
+// where they could open formatted text closed further on.
+func escapeWiki(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '{', '}', '[', ']', '|':
+			b.WriteByte('\\')
+		case '*', '_', '-', '+', '^', '~':
+			opens := (i == 0 || !isWordByte(text[i-1]) && text[i-1] != c) && i+1 < len(text) && !isSpace(text[i+1])
+			if opens && strings.IndexByte(text[i+1:], c) >= 0 {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
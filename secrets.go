@@ -0,0 +1,149 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	jira "github.com/ExpediaGroup/flyte-jira/client"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// authReloaders holds the credentials of every configured jira instance.
+var authReloaders []*authReloader
+
+// secretReader reads credentials either from an env. variable or, when the
+// variable with a _FILE suffix is set, from the file it names. This is how
+// orchestrators such as Kubernetes mount secrets. It remembers the files it
+// read and the first setting that was missing or unreadable.
+type secretReader struct {
+	files map[string][]byte
+	err   error
+}
+
+func (s *secretReader) get(env string) string {
+	value := s.getOptional(env)
+	if value == "" && s.err == nil {
+		s.err = fmt.Errorf("neither %s nor %s_FILE env. variable is set", env, env)
+	}
+	return value
+}
+
+func (s *secretReader) getOptional(env string) string {
+	file := os.Getenv(env + "_FILE")
+	if file == "" {
+		return os.Getenv(env)
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("cannot read %s_FILE: %v", env, err)
+		}
+		return ""
+	}
+	s.remember(file, content)
+	return string(bytes.TrimSpace(content))
+}
+
+// watch records a file the credentials depend on without reading it as a
+// secret value, e.g. a private key.
+func (s *secretReader) watch(file string) {
+	content, _ := ioutil.ReadFile(file)
+	s.remember(file, content)
+}
+
+func (s *secretReader) remember(file string, content []byte) {
+	if s.files == nil {
+		s.files = map[string][]byte{}
+	}
+	s.files[file] = content
+}
+
+// authReloader rebuilds the credentials of one jira instance from its
+// settings and keeps track of the secret files they were read from.
+type authReloader struct {
+	name   string
+	prefix string
+	auth   *jira.ReloadableAuth
+	files  map[string][]byte
+}
+
+func (r *authReloader) load() (jira.Authenticator, error) {
+	s := &secretReader{}
+	auth, err := loadAuth(r.prefix, s)
+	if err != nil {
+		return nil, err
+	}
+	r.files = s.files
+	return auth, nil
+}
+
+// changed tells whether any of the secret files was rotated since the
+// credentials were last loaded.
+func (r *authReloader) changed() bool {
+	for file, loaded := range r.files {
+		content, err := ioutil.ReadFile(file)
+		if err == nil && !bytes.Equal(content, loaded) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *authReloader) reload() {
+	if err := r.auth.Reload(); err != nil {
+		log.Printf("cannot reload credentials of %s jira instance, keeping the current ones: %v", r.name, err)
+		return
+	}
+	log.Printf("reloaded credentials of %s jira instance", r.name)
+}
+
+// watchSecrets reloads the credentials of all jira instances on SIGHUP and
+// those read from files whenever one of the files changes, checking them
+// every JIRA_SECRET_RELOAD_INTERVAL (default 1m, 0 disables the check).
+func watchSecrets() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval := getDurationEnv("JIRA_SECRET_RELOAD_INTERVAL", time.Minute); interval > 0 {
+		tick = time.NewTicker(interval).C
+	}
+
+	go func() {
+		for {
+			select {
+			case <-hangup:
+				for _, r := range authReloaders {
+					r.reload()
+				}
+			case <-tick:
+				for _, r := range authReloaders {
+					if r.changed() {
+						r.reload()
+					}
+				}
+			}
+		}
+	}()
+}